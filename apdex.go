@@ -0,0 +1,39 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+// Apdex ratings, as reported in Data["apdex"]
+const (
+	ApdexSatisfied  = "satisfied"
+	ApdexTolerating = "tolerating"
+	ApdexFrustrated = "frustrated"
+)
+
+/*
+WithApdex makes the middleware rate each request by the Apdex standard (https://www.apdex.org) against threshold,
+e.g. 300 * time.Millisecond, and report the rating in Data["apdex"].  See ApdexRating.  Apdex scores can then be
+worked out server-side as (satisfied + tolerating/2) / total without any further processing.
+*/
+func WithApdex(threshold time.Duration) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Apdex = threshold
+	}
+}
+
+/*
+ApdexRating rates a request that took responseUS microseconds and got status: ApdexSatisfied if it took no longer
+than threshold, ApdexTolerating if it took no longer than four times threshold, and otherwise ApdexFrustrated.
+Server errors, with status 500 and above, are always ApdexFrustrated.
+*/
+func ApdexRating(responseUS, status int, threshold time.Duration) string {
+	took := time.Duration(responseUS) * time.Microsecond
+	switch {
+	case status >= 500 || took > 4*threshold:
+		return ApdexFrustrated
+	case took > threshold:
+		return ApdexTolerating
+	}
+	return ApdexSatisfied
+}
@@ -0,0 +1,58 @@
+/*
+Package asynq contains asynq (https://github.com/hibiken/asynq) middleware for reporting processed tasks to
+Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package asynq
+
+import (
+	"context"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	goasynq "github.com/hibiken/asynq"
+)
+
+/*
+Middleware builds asynq ServeMux middleware that reports each task processed to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	mux := asynq.NewServeMux()
+	mux.Use(Middleware(sender))
+
+Events are built as for apinalytics_client.JobEvent, with Method "TASK" and Function set to the task type.  The queue
+the task came from and the number of times it has been retried are reported in Data["queue"] and
+Data["retry_count"].
+
+The middleware takes options from the apinalytics_client package, e.g. WithRequestSampleRate, WithErrorsOnly,
+WithApdex or WithClock.  Tasks have no HTTP request or path, so options that need one, such as WithRequestID,
+WithRequestCallback, WithExcludedPaths and WithRouteTags, have no effect.
+*/
+func Middleware(sender cli.Queuer, options ...cli.MiddlewareOption) goasynq.MiddlewareFunc {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(h goasynq.Handler) goasynq.Handler {
+		return goasynq.HandlerFunc(func(ctx context.Context, t *goasynq.Task) error {
+			job := &cli.JobEvent{Name: t.Type(), Start: config.Now()}
+
+			err := h.ProcessTask(ctx, t)
+
+			job.Err = err
+			event := job.Event()
+			event.Method = "TASK"
+			if event.Data == nil {
+				event.Data = make(map[string]interface{})
+			}
+			if queue, ok := goasynq.GetQueueName(ctx); ok {
+				event.Data["queue"] = queue
+			}
+			if retries, ok := goasynq.GetRetryCount(ctx); ok {
+				event.Data["retry_count"] = retries
+			}
+			config.Finish(event, job.Start)
+			config.Report(event)
+			return err
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Package aws delivers batches of apinalytics events to AWS managed queues, a Kinesis data stream or an SQS queue, so
+serverless and ECS services can buffer their analytics in AWS on the way to apinalytics.
+
+	transport, err := aws.NewKinesisTransport(ctx, "apinalytics-events")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "", apinalytics_client.WithTransport(transport))
+
+Credentials and region come from the standard AWS SDK chain: environment variables, shared config files, then the
+ECS task role or EC2 instance profile.  Use WithConfig to supply your own aws.Config instead.
+
+Events are encoded as JSON arrays, or with the Codec set WithCodec.  The AWS SDK's own retries are turned off, as the
+Sender retries failures according to its RetryPolicy, and spools them if it has a spool.
+*/
+package aws
+
+import (
+	"context"
+	"errors"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go"
+)
+
+// Settings shared by the transports
+type settings struct {
+	config *awssdk.Config
+	codec  cli.Codec
+}
+
+// Option configures optional behaviour of a KinesisTransport or SQSTransport.
+type Option func(*settings)
+
+/*
+WithConfig makes the transport use cfg, e.g. to set the region or credentials explicitly, rather than loading
+the default configuration.
+*/
+func WithConfig(cfg awssdk.Config) Option {
+	return func(s *settings) {
+		s.config = &cfg
+	}
+}
+
+/*
+WithCodec encodes events with codec rather than apinalytics_client.JSONCodec.
+*/
+func WithCodec(codec cli.Codec) Option {
+	return func(s *settings) {
+		if codec != nil {
+			s.codec = codec
+		}
+	}
+}
+
+// Apply options, and load the default AWS configuration if they didn't supply one
+func newSettings(ctx context.Context, options []Option) (*settings, error) {
+	s := &settings{codec: cli.JSONCodec{}}
+	for _, option := range options {
+		option(s)
+	}
+	if s.config == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.config = &cfg
+	}
+	return s, nil
+}
+
+// Error codes AWS services use to say we're sending too fast
+var throttlingCodes = map[string]bool{
+	"ThrottlingException":                    true,
+	"Throttling":                             true,
+	"RequestThrottled":                       true,
+	"ProvisionedThroughputExceededException": true,
+	"KMSThrottlingException":                 true,
+	"LimitExceededException":                 true,
+}
+
+// Turn an error from AWS into one the Sender knows how to handle.  Only mistakes on our side, such as a stream that
+// doesn't exist or missing permissions, aren't worth retrying
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorFault() == smithy.FaultClient && !throttlingCodes[apiErr.ErrorCode()] {
+		return err
+	}
+	return cli.Temporary(err)
+}
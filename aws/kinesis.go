@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// Limits on a Kinesis PutRecords request
+const (
+	maxKinesisRecords      = 500
+	maxKinesisRecordBytes  = 1 << 20
+	maxKinesisRequestBytes = 5 << 20
+)
+
+/*
+KinesisTransport is an apinalytics_client.Transport that puts batches into a Kinesis data stream.  Create one with
+NewKinesisTransport.
+
+Each batch is split by ConsumerId and the events for each consumer put as one record, with the ConsumerId as its
+partition key, so all of a consumer's events land on the same shard in order.  Events without a ConsumerId are
+partitioned by batch ID.
+*/
+type KinesisTransport struct {
+	client *kinesis.Client
+	stream string
+	codec  cli.Codec
+}
+
+/*
+NewKinesisTransport creates a KinesisTransport putting records into the named stream.  It returns an error if the AWS
+configuration can't be loaded.
+*/
+func NewKinesisTransport(ctx context.Context, stream string, options ...Option) (*KinesisTransport, error) {
+	s, err := newSettings(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("apinalytics: couldn't load AWS configuration. %v", err)
+	}
+	client := kinesis.NewFromConfig(*s.config, func(o *kinesis.Options) {
+		o.RetryMaxAttempts = 1
+	})
+	return &KinesisTransport{client: client, stream: stream, codec: s.codec}, nil
+}
+
+/*
+Send puts the events in payload into the stream, one record per ConsumerId, in as many PutRecords requests as
+Kinesis's limits of 500 records and 5 MiB per request need.  If Kinesis fails some of the records the events in them
+are rejected as retryable, so only they are sent again.
+*/
+func (transport *KinesisTransport) Send(ctx context.Context, payload *cli.Payload) ([]cli.RejectedEvent, error) {
+	// Group the events by consumer, remembering where each came from in the batch
+	var keys []string
+	groups := make(map[string][]int)
+	for i, event := range payload.Batch.Events {
+		if _, ok := groups[event.ConsumerId]; !ok {
+			keys = append(keys, event.ConsumerId)
+		}
+		groups[event.ConsumerId] = append(groups[event.ConsumerId], i)
+	}
+
+	records := make([]kinesistypes.PutRecordsRequestEntry, 0, len(keys))
+	for _, key := range keys {
+		events := make([]*cli.AnalyticsEvent, 0, len(groups[key]))
+		for _, i := range groups[key] {
+			events = append(events, payload.Batch.Events[i])
+		}
+		data, _, err := transport.codec.Marshal(events)
+		if err != nil {
+			return nil, err
+		}
+		partitionKey := key
+		if partitionKey == "" {
+			partitionKey = payload.Batch.ID
+		}
+		// Kinesis counts the partition key towards the size of the record
+		if len(data)+len(partitionKey) > maxKinesisRecordBytes {
+			// The Sender splits the batch and tries again
+			return nil, cli.ErrPayloadTooLarge
+		}
+		records = append(records, kinesistypes.PutRecordsRequestEntry{
+			Data:         data,
+			PartitionKey: awssdk.String(partitionKey),
+		})
+	}
+
+	var rejected []cli.RejectedEvent
+	for start, end := 0, 0; start < len(records); start = end {
+		end = requestEnd(records, start)
+		output, err := transport.client.PutRecords(ctx, &kinesis.PutRecordsInput{
+			StreamName: awssdk.String(transport.stream),
+			Records:    records[start:end],
+		})
+		if err != nil {
+			if start == 0 {
+				return nil, classify(err)
+			}
+			// Earlier records were put, so only ask for the rest to be sent again
+			for _, key := range keys[start:] {
+				rejected = append(rejected, rejectGroup(groups[key], err.Error())...)
+			}
+			return rejected, nil
+		}
+		for r, result := range output.Records {
+			if result.ErrorCode != nil {
+				reason := awssdk.ToString(result.ErrorCode) + ": " + awssdk.ToString(result.ErrorMessage)
+				rejected = append(rejected, rejectGroup(groups[keys[start+r]], reason)...)
+			}
+		}
+	}
+	return rejected, nil
+}
+
+// Where the PutRecords request for the records from start should end, so it's within Kinesis's limits on the number
+// of records and their total size
+func requestEnd(records []kinesistypes.PutRecordsRequestEntry, start int) int {
+	size := 0
+	for end := start; end < len(records); end++ {
+		size += len(records[end].Data) + len(awssdk.ToString(records[end].PartitionKey))
+		if end-start == maxKinesisRecords || (size > maxKinesisRequestBytes && end > start) {
+			return end
+		}
+	}
+	return len(records)
+}
+
+// Reject the events at the given positions in the batch, to be sent again
+func rejectGroup(indexes []int, reason string) []cli.RejectedEvent {
+	rejected := make([]cli.RejectedEvent, 0, len(indexes))
+	for _, i := range indexes {
+		rejected = append(rejected, cli.RejectedEvent{Index: i, Error: reason, Retryable: true})
+	}
+	return rejected
+}
+
+var _ cli.Transport = (*KinesisTransport)(nil)
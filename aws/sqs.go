@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Largest message SQS accepts, including attributes
+const maxSQSMessageBytes = 256 * 1024
+
+/*
+SQSTransport is an apinalytics_client.Transport that sends each batch as a message to an SQS queue.  Create one with
+NewSQSTransport.
+
+Messages carry content-type, batch-id and sdk attributes.  SQS messages must be text without most control characters,
+so output that isn't, e.g. from MessagePack or protobuf codecs, is base64 encoded and a content-transfer-encoding
+attribute of "base64" added.  For
+FIFO queues the batch ID is used as the deduplication ID, so a retried batch is only delivered once.
+*/
+type SQSTransport struct {
+	client   *sqs.Client
+	queueURL string
+	codec    cli.Codec
+	fifo     bool
+}
+
+/*
+NewSQSTransport creates an SQSTransport sending to the queue at queueURL.  It returns an error if the AWS configuration
+can't be loaded.
+*/
+func NewSQSTransport(ctx context.Context, queueURL string, options ...Option) (*SQSTransport, error) {
+	s, err := newSettings(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("apinalytics: couldn't load AWS configuration. %v", err)
+	}
+	client := sqs.NewFromConfig(*s.config, func(o *sqs.Options) {
+		o.RetryMaxAttempts = 1
+	})
+	return &SQSTransport{
+		client:   client,
+		queueURL: queueURL,
+		codec:    s.codec,
+		fifo:     strings.HasSuffix(queueURL, ".fifo"),
+	}, nil
+}
+
+/*
+Send sends the events in payload to the queue as a single message.
+*/
+func (transport *SQSTransport) Send(ctx context.Context, payload *cli.Payload) ([]cli.RejectedEvent, error) {
+	data, contentType, err := transport.codec.Marshal(payload.Batch.Events)
+	if err != nil {
+		return nil, err
+	}
+	attributes := map[string]sqstypes.MessageAttributeValue{
+		"content-type": stringAttribute(contentType),
+		"batch-id":     stringAttribute(payload.Batch.ID),
+		"sdk":          stringAttribute("go/" + cli.SDKVersion),
+	}
+	body := string(data)
+	if !sqsText(data) {
+		body = base64.StdEncoding.EncodeToString(data)
+		attributes["content-transfer-encoding"] = stringAttribute("base64")
+	}
+	if len(body) > maxSQSMessageBytes-1024 {
+		// Leave room for the attributes.  The Sender splits the batch and tries again
+		return nil, cli.ErrPayloadTooLarge
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          awssdk.String(transport.queueURL),
+		MessageBody:       awssdk.String(body),
+		MessageAttributes: attributes,
+	}
+	if transport.fifo {
+		input.MessageGroupId = awssdk.String("apinalytics")
+		input.MessageDeduplicationId = awssdk.String(payload.Batch.ID)
+	}
+	_, err = transport.client.SendMessage(ctx, input)
+	return nil, classify(err)
+}
+
+// Whether data only has characters SQS allows in message bodies: tab, LF, CR and valid UTF-8 from space up, apart from
+// U+FFFE and U+FFFF.  Binary output can be valid UTF-8 and still contain other control characters
+func sqsText(data []byte) bool {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 {
+			return false
+		}
+		if (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || r == 0xFFFE || r == 0xFFFF {
+			return false
+		}
+		data = data[size:]
+	}
+	return true
+}
+
+// Make a string message attribute
+func stringAttribute(value string) sqstypes.MessageAttributeValue {
+	return sqstypes.MessageAttributeValue{DataType: awssdk.String("String"), StringValue: awssdk.String(value)}
+}
+
+var _ cli.Transport = (*SQSTransport)(nil)
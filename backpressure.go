@@ -0,0 +1,43 @@
+package apinalytics_client
+
+import (
+	"sync/atomic"
+)
+
+/*
+DropPolicy decides what Sender.Queue does when the queue to the background goroutine is full, which happens if events
+are queued faster than apinalytics accepts them.
+*/
+type DropPolicy int
+
+const (
+	// Block makes Queue wait until there is space in the queue.  This is the default
+	Block DropPolicy = iota
+	// DropNewest discards the event being queued, leaving the queue unchanged
+	DropNewest
+	// DropOldest discards the longest queued event to make room for the new one
+	DropOldest
+)
+
+/*
+WithDropPolicy sets what Queue does when the queue is full.  With DropNewest or DropOldest Queue never blocks, so
+reporting events can't add latency to your request handlers.  Use Sender.Dropped to see how many events were
+discarded.  To bound how long Queue blocks instead, use QueueContext.
+*/
+func WithDropPolicy(policy DropPolicy) Option {
+	return func(sender *Sender) {
+		sender.dropPolicy = policy
+	}
+}
+
+/*
+Dropped returns the number of events discarded because the queue was full.  It is safe to call concurrently.
+*/
+func (sender *Sender) Dropped() uint64 {
+	return atomic.LoadUint64(&sender.stats.dropped)
+}
+
+// Record that an event was discarded
+func (sender *Sender) drop() {
+	atomic.AddUint64(&sender.stats.dropped, 1)
+}
@@ -0,0 +1,60 @@
+package apinalytics_client
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+/*
+Batch is a batch of events as sent to apinalytics in a single POST.
+
+Every batch has a random ID, sent in the X-Batch-Id header, which stays the same if the batch is retried so that
+apinalytics can discard duplicates.  If the sender was created WithEnvelope the batch is sent as an envelope holding
+the ID and the SDK that sent it alongside the events; otherwise only the events are sent.
+*/
+type Batch struct {
+	// Unique identifier for the batch, a random UUID
+	ID string `json:"batch_id"`
+	// The events in the batch
+	Events []*AnalyticsEvent `json:"events"`
+	// The client library that sent the batch
+	SDK *SDK `json:"sdk,omitempty"`
+	// Response times of the events in the batch by Function, if the sender was created WithBatchSummaries
+	Summaries []LatencySummary `json:"summaries,omitempty"`
+}
+
+/*
+WithEnvelope makes the sender send each batch as a Batch envelope rather than a bare list of events.  With the default
+JSONCodec this is a JSON object like
+
+	{"batch_id": "...", "events": [...], "sdk": {"name": "go", "version": "...", "runtime": "..."}}
+
+Only use this if your apinalytics server understands envelopes.  Codecs that don't implement BatchCodec ignore this
+option.
+*/
+func WithEnvelope() Option {
+	return func(sender *Sender) {
+		sender.envelope = true
+	}
+}
+
+// Make a new batch of events to send
+func (sender *Sender) newBatch(events []*AnalyticsEvent) *Batch {
+	batch := &Batch{ID: newBatchID(), Events: events, SDK: sdk}
+	if sender.summaries && sender.envelope {
+		batch.Summaries = summarize(events)
+	}
+	return batch
+}
+
+// Generate a random (version 4) UUID to identify a batch
+func newBatchID() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		// crypto/rand doesn't fail on any supported platform
+		panic(err)
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40 // Version 4
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
@@ -0,0 +1,71 @@
+package apinalytics_client
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/*
+WithCircuitBreaker stops the sender trying to reach apinalytics once it is clearly down, rather than waiting for a
+timeout on every batch and slowing the drain of the queue.
+
+After threshold consecutive batches fail with a network error or 5xx response the circuit opens.  While it is open
+batches are spooled if the sender has a spool, otherwise they are passed to the error handler with ErrCircuitOpen,
+without any attempt to send them.  Once cooldown has passed the next batch is sent as a probe.  If it succeeds the
+circuit closes, if not the circuit stays open for another cooldown.
+*/
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(sender *Sender) {
+		if threshold > 0 {
+			sender.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown}
+		}
+	}
+}
+
+// A circuit breaker.  Only used from the background goroutine apart from open, which Stats reads.  A nil
+// *circuitBreaker is always closed
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int       // Consecutive failures
+	openedAt  time.Time // When the circuit last opened
+	probing   bool      // True while the cooldown has passed and we're trying a batch
+	open      int32     // 1 while the circuit is open
+}
+
+// Decide whether to attempt a send at now
+func (breaker *circuitBreaker) allow(now time.Time) bool {
+	if breaker == nil || atomic.LoadInt32(&breaker.open) == 0 {
+		return true
+	}
+	if now.Sub(breaker.openedAt) < breaker.cooldown {
+		return false
+	}
+	breaker.probing = true
+	return true
+}
+
+// Record the result of an attempted send, finishing at now
+func (breaker *circuitBreaker) record(ok bool, now time.Time) {
+	if breaker == nil {
+		return
+	}
+	if ok {
+		breaker.failures = 0
+		breaker.probing = false
+		atomic.StoreInt32(&breaker.open, 0)
+		return
+	}
+
+	breaker.failures++
+	if breaker.probing || breaker.failures >= breaker.threshold {
+		breaker.probing = false
+		breaker.openedAt = now
+		atomic.StoreInt32(&breaker.open, 1)
+	}
+}
+
+// Report whether the circuit is open
+func (breaker *circuitBreaker) isOpen() bool {
+	return breaker != nil && atomic.LoadInt32(&breaker.open) == 1
+}
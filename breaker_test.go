@@ -0,0 +1,54 @@
+package apinalytics_client_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+func TestSpoolReplayRespectsCircuitBreaker(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	spool, err := cli.NewFileSpool(filepath.Join(t.TempDir(), "spool"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithSpool(spool),
+		cli.WithCircuitBreaker(1, time.Minute), cli.WithErrorHandler(func(error, []*cli.AnalyticsEvent) {}))
+	defer sender.Close()
+
+	// The first batch fails, is spooled and opens the circuit
+	collector.FailAll(503)
+	sender.Queue(&cli.AnalyticsEvent{Method: "GET", Function: "first", StatusCode: 200})
+	sender.Flush(context.Background())
+	if !sender.Stats().CircuitOpen {
+		t.Fatalf("Expected the circuit to be open")
+	}
+
+	// While the circuit is open nothing reaches apinalytics, spooled or not
+	collector.FailAll(0)
+	sender.Queue(&cli.AnalyticsEvent{Method: "GET", Function: "second", StatusCode: 200})
+	sender.Flush(context.Background())
+	if requests := collector.Requests(); requests != 1 {
+		t.Errorf("Expected no requests while the circuit is open, got %d", requests-1)
+	}
+
+	// Once the cooldown has passed a new batch gets through, closing the circuit, and the spool is replayed after it
+	clock.Advance(2 * time.Minute)
+	sender.Queue(&cli.AnalyticsEvent{Method: "GET", Function: "third", StatusCode: 200})
+	sender.Flush(context.Background())
+	if !collector.WaitForEvents(3, time.Second) {
+		t.Fatalf("Expected 3 events, got %d", collector.EventCount())
+	}
+	if function := collector.Events()[0].Function; function != "third" {
+		t.Errorf("Expected the new batch first, got %s", function)
+	}
+	if sender.Stats().CircuitOpen {
+		t.Errorf("Expected the circuit to be closed")
+	}
+}
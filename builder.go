@@ -0,0 +1,123 @@
+package apinalytics_client
+
+import (
+	"strings"
+	"time"
+)
+
+/*
+EventBuilder builds an AnalyticsEvent a field at a time, filling in defaults and checking the result, e.g.
+
+	err := apinalytics_client.NewEvent().Method("GET").Url("/things/1").Status(200).Duration(took).
+		Consumer(userId).With("plan", "pro").Queue(sender)
+
+Create one with NewEvent.  The first problem found, such as a Data value that can't be sent, is returned by Build or
+Queue.
+*/
+type EventBuilder struct {
+	event *AnalyticsEvent
+	at    time.Time
+	err   error
+}
+
+/*
+NewEvent starts building an event.
+*/
+func NewEvent() *EventBuilder {
+	return &EventBuilder{event: &AnalyticsEvent{}}
+}
+
+// Method sets the HTTP method, e.g. "GET".
+func (builder *EventBuilder) Method(method string) *EventBuilder {
+	builder.event.Method = strings.ToUpper(method)
+	return builder
+}
+
+// Url sets the URL requested, and its Path and Query.
+func (builder *EventBuilder) Url(url string) *EventBuilder {
+	builder.event.Url = url
+	builder.event.Path, builder.event.Query = url, ""
+	if i := strings.IndexByte(url, '?'); i >= 0 {
+		builder.event.Path, builder.event.Query = url[:i], url[i+1:]
+	}
+	return builder
+}
+
+// Function sets the name of the function that handled the request.  It defaults to "unknown".
+func (builder *EventBuilder) Function(function string) *EventBuilder {
+	builder.event.Function = function
+	return builder
+}
+
+// Status sets the HTTP status code of the response.
+func (builder *EventBuilder) Status(status int) *EventBuilder {
+	builder.event.StatusCode = status
+	return builder
+}
+
+// Duration sets how long the request took to handle.
+func (builder *EventBuilder) Duration(d time.Duration) *EventBuilder {
+	builder.event.ResponseUS = int(d.Nanoseconds() / 1000)
+	return builder
+}
+
+// Kind sets what the event records.  It defaults to an API call.
+func (builder *EventBuilder) Kind(kind EventKind) *EventBuilder {
+	builder.event.Kind = kind
+	return builder
+}
+
+// Consumer sets the ID of the API consumer.
+func (builder *EventBuilder) Consumer(id string) *EventBuilder {
+	builder.event.ConsumerId = id
+	return builder
+}
+
+// At sets when the request was made.  It defaults to when the event is built.
+func (builder *EventBuilder) At(t time.Time) *EventBuilder {
+	builder.at = t
+	return builder
+}
+
+// With sets Data[key] to value, which must be JSON serializable.  See AnalyticsEvent.Set.
+func (builder *EventBuilder) With(key string, value interface{}) *EventBuilder {
+	if err := builder.event.Set(key, value); err != nil && builder.err == nil {
+		builder.err = err
+	}
+	return builder
+}
+
+/*
+Build returns the event, with Timestamp and Function defaulted if they weren't set.  It returns an error if a Data
+value couldn't be set or the event fails Validate.  Each call returns a new event.
+*/
+func (builder *EventBuilder) Build() (*AnalyticsEvent, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+	event := builder.event.Clone()
+	at := builder.at
+	if at.IsZero() {
+		at = time.Now()
+	}
+	event.SetTime(at)
+	if event.Function == "" {
+		event.Function = "unknown"
+	}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+/*
+Queue builds the event and queues it to sender, returning any error from Build.
+*/
+func (builder *EventBuilder) Queue(sender Queuer) error {
+	event, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	sender.Queue(event)
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Package chi contains chi (https://github.com/go-chi/chi) middleware for reporting events to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package chi
+
+import (
+	"net/http"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	gochi "github.com/go-chi/chi/v5"
+)
+
+/*
+Middleware builds chi middleware that reports HTTP requests to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	r := chi.NewRouter()
+	r.Use(Middleware(sender, nil))
+
+Events have Function set to the route pattern that matched the request, e.g. "/users/{id}", rather than the raw URL,
+so requests for different users are grouped together.  Requests that match no route report "unknown".
+
+To add your own data to the events reported add a callback, e.g. to record the ID of the API consumer.
+
+	callback := func(event *apinalytics_client.AnalyticsEvent, r *http.Request) {
+		event.ConsumerId = r.Context().Value(userKey).(string)
+	}
+
+	r.Use(Middleware(sender, callback))
+
+Options from the apinalytics_client package, e.g. WithRequestSampleRate, can be added after the callback.  Pass your
+callback as callback rather than with WithRequestCallback, which would stop Function being set.
+*/
+func Middleware(sender cli.Queuer,
+	callback func(event *cli.AnalyticsEvent, r *http.Request),
+	options ...cli.MiddlewareOption,
+) func(http.Handler) http.Handler {
+	resolve := func(event *cli.AnalyticsEvent, r *http.Request) {
+		// chi fills in the route context as the request is routed, so by now it holds the full pattern
+		if rctx := gochi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				event.Function = pattern
+			}
+		}
+		if callback != nil {
+			callback(event, r)
+		}
+	}
+	options = append([]cli.MiddlewareOption{cli.WithRequestCallback(resolve)}, options...)
+
+	return func(h http.Handler) http.Handler {
+		return cli.Wrap(h, sender, options...)
+	}
+}
@@ -0,0 +1,136 @@
+package apinalytics_client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+/*
+ClientIPResolver works out the IP address of the client that made a request.  By default it uses the request's
+RemoteAddr.  If your service is behind proxies or load balancers list them in TrustedProxies and the headers they set
+in Headers; then when a request comes from a trusted proxy the client's address is taken from the headers instead.
+Headers from anyone else are ignored, as clients can set them to anything.
+
+	resolver := ClientIPResolver{
+		Headers:        []string{"X-Forwarded-For"},
+		TrustedProxies: MustParseCIDRs("10.0.0.0/8"),
+		Anonymize:      true,
+	}
+*/
+type ClientIPResolver struct {
+	// Headers to take the client's address from, tried in order: any of "X-Forwarded-For", "X-Real-IP" and
+	// "Forwarded"
+	Headers []string
+	// Networks of the proxies whose headers are trusted
+	TrustedProxies []*net.IPNet
+	// Hide the last octet of IPv4 addresses, and all but the first 48 bits of IPv6 addresses, for privacy
+	Anonymize bool
+}
+
+/*
+MustParseCIDRs parses networks in CIDR notation, e.g. "10.0.0.0/8", for ClientIPResolver.TrustedProxies.  It panics
+if any of them are invalid.
+*/
+func MustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("apinalytics: bad CIDR %q. %v", cidr, err))
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+/*
+ClientIP returns the address of the client that made r, or "" if it can't be found.
+*/
+func (resolver *ClientIPResolver) ClientIP(r *http.Request) string {
+	ip := parseIP(r.RemoteAddr)
+	if ip != nil && resolver.trusted(ip) {
+		for _, header := range resolver.Headers {
+			if forwarded := resolver.fromHeader(r.Header, header); forwarded != nil {
+				ip = forwarded
+				break
+			}
+		}
+	}
+	if ip == nil {
+		return ""
+	}
+	if resolver.Anonymize {
+		ip = anonymize(ip)
+	}
+	return ip.String()
+}
+
+// Find the client's address in a header.  Proxies append addresses to the end of the list, so we work back from
+// there, skipping our own proxies, to find the last address nobody we trust added
+func (resolver *ClientIPResolver) fromHeader(header http.Header, name string) net.IP {
+	var addresses []string
+	switch http.CanonicalHeaderKey(name) {
+	case "X-Forwarded-For":
+		for _, value := range header.Values("X-Forwarded-For") {
+			addresses = append(addresses, strings.Split(value, ",")...)
+		}
+	case "X-Real-Ip":
+		addresses = header.Values("X-Real-Ip")
+	case "Forwarded":
+		for _, value := range header.Values("Forwarded") {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					pair = strings.TrimSpace(pair)
+					if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+						addresses = append(addresses, strings.Trim(pair[4:], `"`))
+					}
+				}
+			}
+		}
+	}
+
+	var ip net.IP
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip = parseIP(strings.TrimSpace(addresses[i]))
+		if ip == nil || !resolver.trusted(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// Check whether an address belongs to one of our proxies
+func (resolver *ClientIPResolver) trusted(ip net.IP) bool {
+	for _, network := range resolver.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse an address which may have a port, and IPv6 addresses may be in brackets
+func parseIP(address string) net.IP {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	return net.ParseIP(strings.Trim(address, "[]"))
+}
+
+func anonymize(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32))
+	}
+	return ip.Mask(net.CIDRMask(48, 128))
+}
+
+/*
+WithClientIP makes the middleware set each event's ClientIP using resolver.
+*/
+func WithClientIP(resolver ClientIPResolver) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.ClientIP = &resolver
+	}
+}
@@ -0,0 +1,58 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+Clock tells the sender the time and makes its flush ticker.  The default uses the time package.  Substitute your own
+with WithClock to control time in tests, e.g. to fire the flush interval without sleeping.
+*/
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTicker returns a Ticker that ticks every d
+	NewTicker(d time.Duration) Ticker
+}
+
+/*
+Ticker is a ticker made by a Clock.  It behaves like a *time.Ticker.
+*/
+type Ticker interface {
+	// C returns the channel the ticks are delivered on
+	C() <-chan time.Time
+	// Stop turns the ticker off
+	Stop()
+}
+
+/*
+WithClock makes the sender use clock rather than the time package for timing sends, timing out the circuit breaker
+and failover, recording errors, ticking the flush interval, and waiting between retries and for Retry-After.  With a
+fake clock, retries wait until the clock is advanced.
+*/
+func WithClock(clock Clock) Option {
+	return func(sender *Sender) {
+		if clock != nil {
+			sender.clock = clock
+		}
+	}
+}
+
+// The default Clock
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (ticker realTicker) C() <-chan time.Time {
+	return ticker.Ticker.C
+}
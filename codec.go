@@ -0,0 +1,69 @@
+package apinalytics_client
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+/*
+Codec encodes a batch of events into the body of a POST to apinalytics.  Marshal returns the encoded batch and the
+Content-Type it should be sent with.
+
+The default codec is JSONCodec.  Implement Codec to send events in another format, e.g. MessagePack or protobuf.
+*/
+type Codec interface {
+	Marshal(events []*AnalyticsEvent) (data []byte, contentType string, err error)
+}
+
+/*
+BatchCodec is a Codec that can also encode a batch in an envelope.  The Sender uses MarshalBatch rather than Marshal
+if it was created WithEnvelope.
+*/
+type BatchCodec interface {
+	Codec
+	MarshalBatch(batch *Batch) (data []byte, contentType string, err error)
+}
+
+// JSONCodec encodes batches of events as a JSON array, or as a JSON object if an envelope is used.
+type JSONCodec struct{}
+
+// Marshal encodes events as a JSON array.
+func (JSONCodec) Marshal(events []*AnalyticsEvent) ([]byte, string, error) {
+	data, err := json.Marshal(events)
+	return data, "application/json", err
+}
+
+// MarshalBatch encodes batch as a JSON object.
+func (JSONCodec) MarshalBatch(batch *Batch) ([]byte, string, error) {
+	data, err := json.Marshal(batch)
+	return data, "application/json", err
+}
+
+/*
+WithCodec makes the sender encode batches of events with codec rather than JSONCodec.  If apinalytics responds 415
+Unsupported Media Type, because it doesn't understand codec's format, the sender falls back to JSONCodec.
+*/
+func WithCodec(codec Codec) Option {
+	return func(sender *Sender) {
+		if codec != nil {
+			sender.codec = codec
+		}
+	}
+}
+
+// Fall back to JSON if err says apinalytics doesn't understand the codec's format, returning true if we did.  Only
+// called from the background goroutine
+func (sender *Sender) fallBack(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnsupportedMediaType {
+		return false
+	}
+	if _, ok := sender.codec.(JSONCodec); ok {
+		return false
+	}
+	log.Printf("apinalytics doesn't accept %T encoded events.  Falling back to JSON\n", sender.codec)
+	sender.codec = JSONCodec{}
+	return true
+}
@@ -0,0 +1,201 @@
+/*
+Package connect contains a Connect (https://connectrpc.com) interceptor for reporting RPCs to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package connect
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	goconnect "connectrpc.com/connect"
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+Interceptor is a connect.Interceptor that reports the unary and streaming RPCs handled by a server to Apinalytics.
+It doesn't report calls made by clients.  Create one with NewInterceptor.
+*/
+type Interceptor struct {
+	callback func(header http.Header, event *cli.AnalyticsEvent)
+	config   cli.MiddlewareConfig
+}
+
+/*
+NewInterceptor creates an Interceptor that reports RPCs to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	path, handler := greetv1connect.NewGreetServiceHandler(impl, connect.WithInterceptors(NewInterceptor(sender, nil)))
+
+Events have Method set to the HTTP method of the call, and Url and Function to the procedure called, e.g.
+"/greet.v1.GreetService/Greet".  StatusCode is the HTTP status closest to the call's Connect error code, which is
+also reported in Data["connect_code"].  For streams ResponseUS is how long the stream was open, and the numbers of
+messages received and sent are reported in Data["messages_received"] and Data["messages_sent"].
+
+To add your own data to the events reported add a callback, which is given the request headers, e.g. to record the
+API consumer.
+
+	callback := func(header http.Header, event *apinalytics_client.AnalyticsEvent) {
+		event.ConsumerId = header.Get("X-Api-User")
+	}
+
+The interceptor takes options from the apinalytics_client package, e.g. WithRequestSampleRate, WithRequestID or
+WithClientIP, which see a request with the call's HTTP method, headers and peer address, and the procedure as its
+path.  ErrorMessage and ErrorType describe the error the handler returned, if any.  WithRequestCallback callbacks are
+given the same request, and are called before callback.
+*/
+func NewInterceptor(sender cli.Queuer,
+	callback func(header http.Header, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) *Interceptor {
+	i := &Interceptor{callback: callback, config: cli.MiddlewareConfig{Sender: sender}}
+	i.config.Apply(options...)
+	return i
+}
+
+/*
+WrapUnary implements connect.Interceptor.
+*/
+func (i *Interceptor) WrapUnary(next goconnect.UnaryFunc) goconnect.UnaryFunc {
+	return func(ctx context.Context, req goconnect.AnyRequest) (goconnect.AnyResponse, error) {
+		if req.Spec().IsClient || !i.config.Paths.Allow(req.Spec().Procedure) {
+			return next(ctx, req)
+		}
+		start := i.config.Now()
+
+		rsp, err := next(ctx, req)
+
+		r := request(ctx, req.Spec().Procedure, req.HTTPMethod(), req.Header(), req.Peer())
+		i.report(r, i.event(r, start, err))
+		return rsp, err
+	}
+}
+
+/*
+WrapStreamingClient implements connect.Interceptor.  Client streams aren't reported.
+*/
+func (i *Interceptor) WrapStreamingClient(next goconnect.StreamingClientFunc) goconnect.StreamingClientFunc {
+	return next
+}
+
+/*
+WrapStreamingHandler implements connect.Interceptor.
+*/
+func (i *Interceptor) WrapStreamingHandler(next goconnect.StreamingHandlerFunc) goconnect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn goconnect.StreamingHandlerConn) error {
+		if !i.config.Paths.Allow(conn.Spec().Procedure) {
+			return next(ctx, conn)
+		}
+		start := i.config.Now()
+		stream := &countingConn{StreamingHandlerConn: conn}
+
+		err := next(ctx, stream)
+
+		// Streams are always POSTs
+		r := request(ctx, conn.Spec().Procedure, http.MethodPost, conn.RequestHeader(), conn.Peer())
+		event := i.event(r, start, err)
+		event.Data["messages_received"] = atomic.LoadInt64(&stream.received)
+		event.Data["messages_sent"] = atomic.LoadInt64(&stream.sent)
+		i.report(r, event)
+		return err
+	}
+}
+
+// Build the event for the call described by r, that started at start and finished with err
+func (i *Interceptor) event(r *http.Request, start time.Time, err error) *cli.AnalyticsEvent {
+	ww := &cli.StatusTrackingResponseWriter{Status: http.StatusOK}
+	if err != nil {
+		ww.Status = httpStatus(goconnect.CodeOf(err))
+	}
+	event := i.config.NewEvent(r, start, ww)
+	event.Function = r.URL.Path
+	if event.Data == nil {
+		event.Data = make(map[string]interface{})
+	}
+	if err != nil {
+		event.Data["connect_code"] = goconnect.CodeOf(err).String()
+		cli.SetError(event, err)
+	}
+	return event
+}
+
+func (i *Interceptor) report(r *http.Request, event *cli.AnalyticsEvent) {
+	if i.config.Callback != nil {
+		i.config.Callback(event, r)
+	}
+	if i.callback != nil {
+		i.callback(r.Header, event)
+	}
+	i.config.Report(event)
+}
+
+// Make up an *http.Request describing a call to procedure, so the middleware settings in apinalytics_client can be
+// applied to it
+func request(ctx context.Context, procedure, method string, header http.Header, peer goconnect.Peer) *http.Request {
+	r := &http.Request{
+		Method:     method,
+		URL:        &url.URL{Path: procedure},
+		RequestURI: procedure,
+		Header:     header,
+		RemoteAddr: peer.Addr,
+	}
+	return r.WithContext(ctx)
+}
+
+// A StreamingHandlerConn that counts the messages passing through it.  Handlers may send and receive from different
+// goroutines, so the counts are updated atomically
+type countingConn struct {
+	goconnect.StreamingHandlerConn
+	received int64
+	sent     int64
+}
+
+func (c *countingConn) Receive(m interface{}) error {
+	err := c.StreamingHandlerConn.Receive(m)
+	if err == nil {
+		atomic.AddInt64(&c.received, 1)
+	}
+	return err
+}
+
+func (c *countingConn) Send(m interface{}) error {
+	err := c.StreamingHandlerConn.Send(m)
+	if err == nil {
+		atomic.AddInt64(&c.sent, 1)
+	}
+	return err
+}
+
+// The HTTP status closest to a Connect error code, as in the Connect protocol specification
+func httpStatus(code goconnect.Code) int {
+	switch code {
+	case goconnect.CodeCanceled:
+		return 499
+	case goconnect.CodeInvalidArgument, goconnect.CodeFailedPrecondition, goconnect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case goconnect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case goconnect.CodeNotFound:
+		return http.StatusNotFound
+	case goconnect.CodeAlreadyExists, goconnect.CodeAborted:
+		return http.StatusConflict
+	case goconnect.CodePermissionDenied:
+		return http.StatusForbidden
+	case goconnect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case goconnect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case goconnect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case goconnect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+var _ goconnect.Interceptor = (*Interceptor)(nil)
@@ -0,0 +1,94 @@
+package apinalytics_client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+ConsumerExtractor works out the ID of the API consumer that made a request, returning "" if it can't.  FromHeader,
+FromBasicAuthUser and FromJWTClaim cover the common cases.
+*/
+type ConsumerExtractor func(r *http.Request) string
+
+/*
+WithConsumerID makes the middleware set each event's ConsumerId using the first of extractors that finds one, e.g.
+
+	apinalytics_client.WithConsumerID(FromJWTClaim("sub"), FromHeader("X-Api-Key"))
+
+A request callback can still override it.
+*/
+func WithConsumerID(extractors ...ConsumerExtractor) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Consumer = append(c.Consumer, extractors...)
+	}
+}
+
+/*
+ConsumerID returns the consumer ID found by the first of extractors that finds one, or "" if none do.  It is for
+middleware outside this package.
+*/
+func ConsumerID(r *http.Request, extractors []ConsumerExtractor) string {
+	for _, extractor := range extractors {
+		if id := extractor(r); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+/*
+FromHeader identifies consumers by the value of the named request header, e.g. "X-Api-Key".  Consider hashing IDs
+that are secrets.
+*/
+func FromHeader(name string) ConsumerExtractor {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+/*
+FromBasicAuthUser identifies consumers by the user name in the request's HTTP Basic Authorization header.
+*/
+func FromBasicAuthUser() ConsumerExtractor {
+	return func(r *http.Request) string {
+		user, _, _ := r.BasicAuth()
+		return user
+	}
+}
+
+/*
+FromJWTClaim identifies consumers by the named claim, e.g. "sub", of the JWT bearer token in the request's
+Authorization header.  The token's signature is NOT checked, so the ID is only as trustworthy as the middleware that
+authenticates requests, which must reject requests with bad tokens before they are reported.
+*/
+func FromJWTClaim(claim string) ConsumerExtractor {
+	return func(r *http.Request) string {
+		auth := r.Header.Get("Authorization")
+		if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+			return ""
+		}
+		parts := strings.Split(strings.TrimSpace(auth[7:]), ".")
+		if len(parts) != 3 {
+			return ""
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+		if err != nil {
+			return ""
+		}
+		var claims map[string]interface{}
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			return ""
+		}
+		switch value := claims[claim].(type) {
+		case string:
+			return value
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return ""
+	}
+}
@@ -0,0 +1,65 @@
+package apinalytics_client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*
+Set sets Data[key] to value, which may be any JSON serializable value: a string, number or bool, or a slice or map of
+them.  It returns an error, leaving Data alone, if value can't be encoded as JSON.
+*/
+func (event *AnalyticsEvent) Set(key string, value interface{}) error {
+	if err := checkValue(value); err != nil {
+		return fmt.Errorf("apinalytics: data %q: %v", key, err)
+	}
+	event.set(key, value)
+	return nil
+}
+
+// SetInt sets Data[key] to the number value.
+func (event *AnalyticsEvent) SetInt(key string, value int64) {
+	event.set(key, value)
+}
+
+// SetFloat sets Data[key] to the number value.  NaN and infinities can't be sent, so are removed by the Sender.
+func (event *AnalyticsEvent) SetFloat(key string, value float64) {
+	event.set(key, value)
+}
+
+// SetBool sets Data[key] to value.
+func (event *AnalyticsEvent) SetBool(key string, value bool) {
+	event.set(key, value)
+}
+
+// Set a Data value, creating Data if need be
+func (event *AnalyticsEvent) set(key string, value interface{}) {
+	if event.Data == nil {
+		event.Data = make(map[string]interface{})
+	}
+	event.Data[key] = value
+}
+
+// Check a Data value can be encoded as JSON
+func checkValue(value interface{}) error {
+	switch value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		// Common cases that always work
+		return nil
+	}
+	_, err := json.Marshal(value)
+	return err
+}
+
+/*
+Remove Data values that can't be encoded as JSON, which would otherwise stop the whole batch being sent, and tell the
+error handler about them.  Only called from the background goroutine
+*/
+func (sender *Sender) checkData(event *AnalyticsEvent) {
+	for key, value := range event.Data {
+		if err := checkValue(value); err != nil {
+			delete(event.Data, key)
+			sender.errorHandler(fmt.Errorf("apinalytics: dropped data %q from event. %v", key, err), nil)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+WithDeduplication makes the sender collapse identical API calls, i.e. HTTP events with the same ConsumerId, Method,
+Url, Function and StatusCode, that arrive within window of the first into that first event, so aggressive client
+retries don't flood apinalytics.  The event kept has Data["repeat_count"] set to the number of times it was seen,
+including itself.  Other kinds of event, such as jobs and metrics, are never collapsed.
+
+Events can only be collapsed into one that hasn't been sent yet.  If the first event has been sent when a duplicate
+arrives within the window, the duplicate is kept and later ones are collapsed into it, so each run of duplicates
+reaches apinalytics as at most one event per flush, and the repeat_counts add up to the number of calls.
+*/
+func WithDeduplication(window time.Duration) Option {
+	return func(sender *Sender) {
+		if window > 0 {
+			sender.dedup = &deduper{window: window, seen: make(map[dedupKey]*dedupEntry)}
+		}
+	}
+}
+
+// What makes events duplicates of each other
+type dedupKey struct {
+	kind       EventKind
+	consumerId string
+	method     string
+	url        string
+	function   string
+	statusCode int
+}
+
+// A run of duplicate events
+type dedupEntry struct {
+	event *AnalyticsEvent // Waiting to be sent, with the later duplicates collapsed into it.  nil once sent
+	first time.Time       // When the first of the run arrived, which starts the window
+	count int             // Number of events collapsed into event, including itself
+}
+
+// Collapses duplicate events waiting to be sent.  Only used from the background goroutine
+type deduper struct {
+	window time.Duration
+	seen   map[dedupKey]*dedupEntry
+}
+
+// Collapse event into an earlier duplicate arriving at now, returning true if it was, in which case it should be
+// discarded.  A nil deduper collapses nothing
+func (d *deduper) collapse(event *AnalyticsEvent, now time.Time) bool {
+	if d == nil || !event.IsHTTP() {
+		return false
+	}
+	key := keyOf(event)
+	entry, ok := d.seen[key]
+	if !ok || now.Sub(entry.first) > d.window {
+		d.seen[key] = &dedupEntry{event: event, first: now, count: 1}
+		return false
+	}
+	if entry.event == nil {
+		// The last event of the run has been sent, so this one carries on for it
+		entry.event = event
+		entry.count = 1
+		return false
+	}
+	entry.count++
+	entry.event.set("repeat_count", entry.count)
+	return true
+}
+
+// Forget event, which has been discarded without being sent, so later duplicates aren't collapsed into it and lost
+func (d *deduper) forget(event *AnalyticsEvent) {
+	if d == nil || !event.IsHTTP() {
+		return
+	}
+	key := keyOf(event)
+	if entry, ok := d.seen[key]; ok && entry.event == event {
+		delete(d.seen, key)
+	}
+}
+
+// The key event is deduplicated by
+func keyOf(event *AnalyticsEvent) dedupKey {
+	return dedupKey{event.Kind, event.ConsumerId, event.Method, event.Url, event.Function, event.StatusCode}
+}
+
+// Note that the events waiting have been sent at now, so nothing more can be collapsed into them, and forget runs whose
+// window has passed
+func (d *deduper) sent(now time.Time) {
+	if d == nil {
+		return
+	}
+	for key, entry := range d.seen {
+		if now.Sub(entry.first) > d.window {
+			delete(d.seen, key)
+		} else {
+			entry.event = nil
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package apinalytics_client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+// Queue events to a sender that deduplicates, and return what reaches apinalytics
+func sendDeduplicated(t *testing.T, events ...*cli.AnalyticsEvent) []*cli.AnalyticsEvent {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithFlushInterval(time.Hour),
+		cli.WithDeduplication(time.Minute))
+	for _, event := range events {
+		sender.Queue(event)
+	}
+	sender.Close()
+	return collector.Events()
+}
+
+func TestDeduplicationCollapsesRepeatedCalls(t *testing.T) {
+	events := sendDeduplicated(t,
+		&cli.AnalyticsEvent{ConsumerId: "alice", Method: "GET", Url: "/a", Function: "get", StatusCode: 200},
+		&cli.AnalyticsEvent{ConsumerId: "alice", Method: "GET", Url: "/a", Function: "get", StatusCode: 200},
+		&cli.AnalyticsEvent{ConsumerId: "alice", Method: "GET", Url: "/a", Function: "get", StatusCode: 500},
+	)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if count := events[0].Data["repeat_count"]; count != float64(2) {
+		t.Errorf("Expected repeat_count 2, got %v", count)
+	}
+	if _, ok := events[1].Data["repeat_count"]; ok {
+		t.Errorf("Event with a different status was collapsed")
+	}
+}
+
+func TestDeduplicationKeepsDifferentJobs(t *testing.T) {
+	start := time.Unix(1000, 0)
+	events := sendDeduplicated(t,
+		(&cli.JobEvent{Name: "nightly-report", Start: start}).Event(),
+		(&cli.JobEvent{Name: "cleanup", Start: start}).Event(),
+		(&cli.JobEvent{Name: "cleanup", Start: start}).Event(),
+	)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if _, ok := event.Data["repeat_count"]; ok {
+			t.Errorf("Job %s was collapsed", event.Function)
+		}
+	}
+}
+
+func TestDeduplicationKeepsDifferentFunctions(t *testing.T) {
+	events := sendDeduplicated(t,
+		&cli.AnalyticsEvent{Method: "GET", Url: "/a", Function: "one", StatusCode: 200},
+		&cli.AnalyticsEvent{Method: "GET", Url: "/a", Function: "two", StatusCode: 200},
+	)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+}
+
+func TestDeduplicationAfterPauseBufferEviction(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithFlushInterval(time.Hour),
+		cli.WithDeduplication(time.Minute), cli.WithPauseBuffer(1))
+	sender.Pause()
+	retry := func() *cli.AnalyticsEvent {
+		return &cli.AnalyticsEvent{Method: "GET", Url: "/a", Function: "get", StatusCode: 200}
+	}
+
+	// The retries are collapsed, then evicted to make room for the next call
+	sender.Queue(retry())
+	sender.Queue(retry())
+	sender.Queue(&cli.AnalyticsEvent{Method: "GET", Url: "/b", Function: "get", StatusCode: 200})
+	// So this one has nothing to be collapsed into, and is kept
+	sender.Queue(retry())
+	sender.Close()
+
+	events := collector.Events()
+	if len(events) != 1 || events[0].Url != "/a" {
+		t.Fatalf("Expected the last retry to be kept, got %v", events)
+	}
+	if dropped := sender.Stats().Dropped; dropped != 2 {
+		t.Errorf("Expected 2 dropped events, got %d", dropped)
+	}
+}
+
+func TestDeduplicationWindowSpansFlushes(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithFlushInterval(time.Hour),
+		cli.WithDeduplication(time.Minute))
+	retry := func() {
+		sender.Queue(&cli.AnalyticsEvent{Method: "GET", Url: "/a", Function: "get", StatusCode: 200})
+	}
+
+	retry()
+	sender.Flush(context.Background())
+	// Within the window, after the first was sent, the retries are collapsed into one trailing event
+	clock.Advance(10 * time.Second)
+	retry()
+	retry()
+	retry()
+	sender.Flush(context.Background())
+	// Once the window has passed a new one starts
+	clock.Advance(time.Minute)
+	retry()
+	sender.Close()
+
+	events := collector.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	if count := events[1].Data["repeat_count"]; count != float64(3) {
+		t.Errorf("Expected repeat_count 3 on the trailing event, got %v", count)
+	}
+	for _, i := range []int{0, 2} {
+		if _, ok := events[i].Data["repeat_count"]; ok {
+			t.Errorf("Event %d has a repeat_count", i)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package apinalytics_client
+
+import (
+	"os"
+)
+
+/*
+EventDefaults are values a Sender stamps on every event it sends, so events from different instances and deployments
+of your service can be told apart.  Fields already set on an event are left alone.
+*/
+type EventDefaults struct {
+	// Host the sender runs on.  Defaults to os.Hostname()
+	Hostname string
+	// Name of the service sending events
+	ServiceName string
+	// Deployment environment, e.g. "production" or "staging"
+	Environment string
+	// Version of the service, e.g. a release tag or commit
+	Version string
+}
+
+/*
+WithHostname overrides the host name stamped on every event, which defaults to the one reported by os.Hostname.  Pass
+"" to leave Hostname unset.
+*/
+func WithHostname(hostname string) Option {
+	return func(sender *Sender) {
+		sender.defaults.Hostname = hostname
+	}
+}
+
+/*
+WithServiceName stamps name on every event as its ServiceName.
+*/
+func WithServiceName(name string) Option {
+	return func(sender *Sender) {
+		sender.defaults.ServiceName = name
+	}
+}
+
+/*
+WithEnvironment stamps environment, e.g. "production", on every event as its Environment.
+*/
+func WithEnvironment(environment string) Option {
+	return func(sender *Sender) {
+		sender.defaults.Environment = environment
+	}
+}
+
+/*
+WithVersion stamps version, e.g. your service's release tag, on every event as its Version.
+*/
+func WithVersion(version string) Option {
+	return func(sender *Sender) {
+		sender.defaults.Version = version
+	}
+}
+
+/*
+WithEventDefaults sets all the values stamped on every event at once, replacing the default Hostname too.
+*/
+func WithEventDefaults(defaults EventDefaults) Option {
+	return func(sender *Sender) {
+		sender.defaults = defaults
+	}
+}
+
+// Work out the default host name.  Empty if the OS won't tell us
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// Fill in the fields of event that aren't already set
+func (defaults EventDefaults) apply(event *AnalyticsEvent) {
+	if event.Hostname == "" {
+		event.Hostname = defaults.Hostname
+	}
+	if event.ServiceName == "" {
+		event.ServiceName = defaults.ServiceName
+	}
+	if event.Environment == "" {
+		event.Environment = defaults.Environment
+	}
+	if event.Version == "" {
+		event.Version = defaults.Version
+	}
+}
@@ -0,0 +1,30 @@
+package apinalytics_client
+
+/*
+Enricher adds to or modifies an event before it is sent, e.g. to stamp on the host name or service version.
+*/
+type Enricher func(event *AnalyticsEvent)
+
+/*
+WithEnrichers adds enrichers that are applied, in order, to every event before it is batched for sending, regardless
+of where the event was queued.  WithEnrichers may be given more than once; later enrichers run after earlier ones.
+
+Enrichers run in the sender's background goroutine, so they don't add latency to Queue, but they do hold up sending
+and should be quick.  As events are modified after Queue returns, don't change an event once you have queued it.
+*/
+func WithEnrichers(enrichers ...Enricher) Option {
+	return func(sender *Sender) {
+		for _, enricher := range enrichers {
+			if enricher != nil {
+				sender.enrichers = append(sender.enrichers, enricher)
+			}
+		}
+	}
+}
+
+// Apply the sender's enrichers to an event
+func (sender *Sender) enrich(event *AnalyticsEvent) {
+	for _, enricher := range sender.enrichers {
+		enricher(event)
+	}
+}
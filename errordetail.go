@@ -0,0 +1,72 @@
+package apinalytics_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Longest ErrorMessage sent.  Longer messages are truncated
+const maxErrorMessage = 1024
+
+// The context key for a request's errorRecorder
+type errorRecorderKey struct{}
+
+// Holds the error a handler recorded
+type errorRecorder struct {
+	err error
+}
+
+/*
+RecordError records err as the reason the request with context ctx failed, so the middleware reports it in the
+event's ErrorMessage and ErrorType.  Call it from your handlers, e.g.
+
+	if err != nil {
+		apinalytics_client.RecordError(r.Context(), err)
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+It returns false, and does nothing, if the request isn't being handled by the middleware.  If it's called more than
+once the last error is reported.
+*/
+func RecordError(ctx context.Context, err error) bool {
+	recorder, ok := ctx.Value(errorRecorderKey{}).(*errorRecorder)
+	if ok {
+		recorder.err = err
+	}
+	return ok
+}
+
+/*
+RecordErrors returns a copy of r whose context lets handlers record errors with RecordError.  It is for middleware
+outside this package, which should pass the copy to the handler and report RecordedError afterwards.
+*/
+func RecordErrors(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), errorRecorderKey{}, &errorRecorder{}))
+}
+
+/*
+RecordedError returns the error recorded with RecordError for the request with context ctx, or nil if there isn't one.
+*/
+func RecordedError(ctx context.Context) error {
+	if recorder, ok := ctx.Value(errorRecorderKey{}).(*errorRecorder); ok {
+		return recorder.err
+	}
+	return nil
+}
+
+/*
+SetError sets event's ErrorMessage to err's message, truncated if it's very long, and ErrorType to err's type, e.g.
+"*fs.PathError".  It does nothing if err is nil.
+*/
+func SetError(event *AnalyticsEvent, err error) {
+	if err == nil {
+		return
+	}
+	event.ErrorMessage = err.Error()
+	if len(event.ErrorMessage) > maxErrorMessage {
+		event.ErrorMessage = event.ErrorMessage[:maxErrorMessage]
+	}
+	event.ErrorType = fmt.Sprintf("%T", err)
+}
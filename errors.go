@@ -0,0 +1,101 @@
+package apinalytics_client
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ErrSenderClosed is returned when an operation is attempted on a Sender that has been closed.
+var ErrSenderClosed = errors.New("apinalytics: sender closed")
+
+// ErrPaused is returned by Flush when the sender is paused.  See Sender.Pause.
+var ErrPaused = errors.New("apinalytics: sender paused")
+
+// ErrQueueFull is returned by QueueContext when an event is discarded because the queue is full.
+var ErrQueueFull = errors.New("apinalytics: queue full")
+
+// ErrCircuitOpen is the error given for batches not sent because the circuit breaker is open.  See WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("apinalytics: circuit breaker open")
+
+/*
+ErrPayloadTooLarge is the error given for an event that could not be sent because on its own it is larger than the
+limit set by WithMaxPayloadBytes, or than apinalytics accepts.
+*/
+var ErrPayloadTooLarge = errors.New("apinalytics: payload too large")
+
+// ErrRejected is the error given for events apinalytics rejected from a batch it otherwise accepted.
+var ErrRejected = errors.New("apinalytics: event rejected")
+
+// StatusError is returned when apinalytics responds to a POST with a status other than 200 OK.
+type StatusError struct {
+	// HTTP status code returned
+	StatusCode int
+	// HTTP status line returned, e.g. "503 Service Unavailable"
+	Status string
+	// How long apinalytics asked us to wait before sending again, from the Retry-After header of a 429 or 503
+	// response.  Zero if it didn't say
+	RetryAfter time.Duration
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("apinalytics: post failed with status %s", err.Status)
+}
+
+/*
+ErrorHandler is called by the Sender's background goroutine when a batch of events could not be sent, with the reason
+and the events that were dropped.  It must not block for long as no events are sent while it runs.
+*/
+type ErrorHandler func(err error, events []*AnalyticsEvent)
+
+/*
+WithErrorHandler routes send failures to handler rather than the standard logger, e.g. to feed structured logging or
+alerting.
+*/
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(sender *Sender) {
+		if handler != nil {
+			sender.errorHandler = handler
+		}
+	}
+}
+
+// Decide whether a failed POST failed because the batch was too big
+func tooLarge(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusRequestEntityTooLarge
+	}
+	return err == ErrPayloadTooLarge
+}
+
+// The default ErrorHandler.  Logs the failure to the standard logger
+func logError(err error, events []*AnalyticsEvent) {
+	log.Printf("Failed to send %d analytics events.  %v\n", len(events), err)
+}
+
+/*
+OnDeadLetter registers fn to be called with each batch of events that could not be delivered, once retries are
+exhausted and the batch could not be spooled, so your application can decide their fate (write them to a file,
+publish them to Kafka, etc).  fn is called from the background goroutine after the error handler, and must not block
+for long.
+*/
+func OnDeadLetter(fn func(events []*AnalyticsEvent)) Option {
+	return func(sender *Sender) {
+		sender.deadLetter = fn
+	}
+}
+
+// Record a batch that couldn't be sent, and pass it to the error handler and hooks
+func (sender *Sender) fail(err error, events []*AnalyticsEvent) {
+	sender.stats.failed(err, sender.clock.Now())
+	sender.errorHandler(err, events)
+	if sender.batchFailed != nil {
+		sender.batchFailed(err, len(events))
+	}
+	if sender.deadLetter != nil {
+		sender.deadLetter(events)
+	}
+}
@@ -0,0 +1,35 @@
+package apinalytics_client
+
+import (
+	"expvar"
+)
+
+/*
+PublishExpvar publishes the sender's statistics through expvar under namespace, so they appear on the standard
+/debug/vars endpoint.  With namespace "apinalytics" the published values are
+
+	apinalytics.sent          - events successfully posted
+	apinalytics.batches_sent  - batches successfully posted
+	apinalytics.failed        - batches that could not be sent
+	apinalytics.dropped       - events discarded because the queue was full
+	apinalytics.queue_depth   - events waiting to be sent
+	apinalytics.last_error    - the most recent send failure, if any
+
+expvar names are global, so like expvar.Publish this panics if namespace is already in use.
+*/
+func (sender *Sender) PublishExpvar(namespace string) {
+	expvar.Publish(namespace, expvar.Func(func() interface{} {
+		stats := sender.Stats()
+		vars := map[string]interface{}{
+			"sent":         stats.EventsSent,
+			"batches_sent": stats.BatchesSent,
+			"failed":       stats.SendFailures,
+			"dropped":      stats.Dropped,
+			"queue_depth":  stats.Queued,
+		}
+		if stats.LastError != nil {
+			vars["last_error"] = stats.LastError.Error()
+		}
+		return vars
+	}))
+}
@@ -0,0 +1,80 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+WithFallbackURLs gives the sender URLs to fail over to when the URL passed to NewSender is failing.
+
+After several consecutive POSTs fail with a network error or 5xx response the sender moves on to the next URL in the
+list, wrapping around at the end.  While it isn't using the primary URL it periodically tries the primary again, and
+fails back as soon as a POST to it succeeds.  By default the sender fails over after 3 failures and tries the primary
+again every minute; use WithFailoverPolicy to change this.
+*/
+func WithFallbackURLs(urls ...string) Option {
+	return func(sender *Sender) {
+		sender.endpoints.urls = append(sender.endpoints.urls, urls...)
+	}
+}
+
+/*
+WithFailoverPolicy sets how many consecutive failures make the sender fail over to the next URL, and how often it
+tries the primary URL again once it has.  It has no effect without WithFallbackURLs.
+*/
+func WithFailoverPolicy(threshold int, probeInterval time.Duration) Option {
+	return func(sender *Sender) {
+		if threshold > 0 {
+			sender.endpoints.threshold = threshold
+		}
+		if probeInterval > 0 {
+			sender.endpoints.probeInterval = probeInterval
+		}
+	}
+}
+
+// Chooses which URL to post to.  Only used from the background goroutine
+type endpoints struct {
+	urls          []string // Primary first
+	threshold     int
+	probeInterval time.Duration
+	current       int       // Index of the URL in use
+	failures      int       // Consecutive failures against the URL in use
+	lastProbe     time.Time // When we failed over, or last tried the primary
+	probing       bool      // True if the last URL handed out was the primary, while failed over
+}
+
+// Choose the URL for a POST at now
+func (e *endpoints) next(now time.Time) string {
+	e.probing = false
+	if e.current != 0 && now.Sub(e.lastProbe) >= e.probeInterval {
+		e.probing = true
+		e.lastProbe = now
+		return e.urls[0]
+	}
+	return e.urls[e.current]
+}
+
+// Record the result of a POST to the URL last returned by next, finishing at now.  ok is false if the endpoint seems
+// to be down
+func (e *endpoints) record(ok bool, now time.Time) {
+	if e.probing {
+		if ok {
+			// The primary is back
+			e.current = 0
+			e.failures = 0
+		}
+		return
+	}
+	if ok {
+		e.failures = 0
+		return
+	}
+
+	e.failures++
+	if e.failures >= e.threshold && len(e.urls) > 1 {
+		e.current = (e.current + 1) % len(e.urls)
+		e.failures = 0
+		e.lastProbe = now
+	}
+}
@@ -0,0 +1,62 @@
+package apinalytics_client
+
+import (
+	"sync"
+)
+
+/*
+FanoutSender delivers every event queued to it to several Queuers, e.g. Senders for apinalytics and an archive.  Each
+Sender keeps its own queue, batching, retries and error handling, so a failure delivering to one doesn't hold up or lose events
+for the others.  Create one with NewFanoutSender.
+
+Queue blocks if any of the Senders blocks, so give them a DropPolicy other than Block if one destination mustn't slow
+down the rest.
+*/
+type FanoutSender struct {
+	senders []Queuer
+}
+
+/*
+NewFanoutSender creates a FanoutSender delivering to senders.
+*/
+func NewFanoutSender(senders ...Queuer) *FanoutSender {
+	return &FanoutSender{senders: senders}
+}
+
+/*
+Queue queues event to every sender.  Each sender gets its own copy, as senders may modify events as they process
+them.
+*/
+func (fanout *FanoutSender) Queue(event *AnalyticsEvent) {
+	if event == nil {
+		return
+	}
+	for i, sender := range fanout.senders {
+		if i == len(fanout.senders)-1 {
+			// The last sender can have the original
+			sender.Queue(event)
+		} else {
+			sender.Queue(event.Clone())
+		}
+	}
+}
+
+/*
+Close closes every sender, in parallel, and waits for their queued events to be sent.
+*/
+func (fanout *FanoutSender) Close() {
+	closeAll(fanout.senders)
+}
+
+// Close senders in parallel and wait for them all to finish
+func closeAll(senders []Queuer) {
+	var wg sync.WaitGroup
+	for _, sender := range senders {
+		wg.Add(1)
+		go func(sender Queuer) {
+			defer wg.Done()
+			sender.Close()
+		}(sender)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,63 @@
+/*
+Package fasthttp contains a wrapper for fasthttp (https://github.com/valyala/fasthttp) request handlers that reports
+events to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package fasthttp
+
+import (
+	cli "github.com/apinalytics/apinalytics_client"
+	fh "github.com/valyala/fasthttp"
+)
+
+/*
+Wrap wraps a fasthttp request handler so that each request it handles is reported to sender.  Share one Sender
+between all your handlers.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	fasthttp.ListenAndServe(":8080", Wrap(handler, sender, nil))
+
+The wrapper sets the same event fields as apinalytics_client.Wrap, apart from ErrorMessage and ErrorType as fasthttp
+handlers can't use RecordError.  Function is "unknown" unless you set it in a callback.
+
+	callback := func(event *apinalytics_client.AnalyticsEvent, ctx *fasthttp.RequestCtx) {
+		event.Function = string(ctx.Path())
+		event.ConsumerId = string(ctx.Request.Header.Peek("X-Api-User"))
+	}
+
+The callback must not keep ctx, or anything from it, beyond the call.  Options from the apinalytics_client package,
+e.g. WithRequestSampleRate or WithClientIP, can be added after the callback.  A callback added with
+WithRequestCallback is called before callback, with the request as made by NewRequest.
+*/
+func Wrap(h fh.RequestHandler, sender cli.Queuer,
+	callback func(event *cli.AnalyticsEvent, ctx *fh.RequestCtx),
+	options ...cli.MiddlewareOption,
+) fh.RequestHandler {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(ctx *fh.RequestCtx) {
+		if !config.Paths.Allow(string(ctx.Path())) {
+			h(ctx)
+			return
+		}
+		start := config.Now()
+
+		h(ctx)
+
+		r := NewRequest(ctx)
+		ww := &cli.StatusTrackingResponseWriter{
+			Status: ctx.Response.StatusCode(),
+			Bytes:  ResponseBytes(&ctx.Response),
+		}
+		event := config.NewEvent(r, start, ww)
+		if config.Callback != nil {
+			config.Callback(event, r)
+		}
+		if callback != nil {
+			callback(event, ctx)
+		}
+		config.Report(event)
+	}
+}
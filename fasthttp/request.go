@@ -0,0 +1,55 @@
+package fasthttp
+
+import (
+	"net/http"
+	"net/url"
+
+	fh "github.com/valyala/fasthttp"
+)
+
+/*
+NewRequest creates an *http.Request describing the request ctx is handling, with the method, URL, headers, remote
+address and content length the middleware in the apinalytics_client package reports.  It has no body, and the content
+length comes from the Content-Length header, so streamed request bodies aren't read.
+
+fasthttp reuses its buffers once the handler returns, so everything is copied, and the request can be kept as long as
+you like.
+*/
+func NewRequest(ctx *fh.RequestCtx) *http.Request {
+	r := &http.Request{
+		Method:        string(ctx.Method()),
+		RequestURI:    string(ctx.RequestURI()),
+		Host:          string(ctx.Host()),
+		RemoteAddr:    ctx.RemoteAddr().String(),
+		ContentLength: int64(ctx.Request.Header.ContentLength()),
+		Header:        make(http.Header),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		TLS:           ctx.TLSConnectionState(),
+	}
+	var err error
+	if r.URL, err = url.ParseRequestURI(r.RequestURI); err != nil {
+		// fasthttp has already made sense of the URI, so use its parts
+		r.URL = &url.URL{Path: string(ctx.Path()), RawQuery: string(ctx.QueryArgs().QueryString())}
+	}
+	for key, value := range ctx.Request.Header.All() {
+		r.Header.Add(string(key), string(value))
+	}
+	return r
+}
+
+/*
+ResponseBytes returns the size of the body of resp without reading it.  Streamed bodies, e.g. from SendFile or
+SetBodyStreamWriter, can't be measured until they've been sent, so their size is taken from the Content-Length header,
+or is 0 if that isn't set.
+*/
+func ResponseBytes(resp *fh.Response) int64 {
+	if resp.IsBodyStream() {
+		if length := resp.Header.ContentLength(); length > 0 {
+			return int64(length)
+		}
+		return 0
+	}
+	return int64(len(resp.Body()))
+}
@@ -0,0 +1,84 @@
+/*
+Package fiber contains Fiber (https://github.com/gofiber/fiber) v2 middleware for reporting events to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package fiber
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	apifasthttp "github.com/apinalytics/apinalytics_client/fasthttp"
+	gofiber "github.com/gofiber/fiber/v2"
+)
+
+/*
+Middleware builds Fiber middleware that reports HTTP requests to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	app := fiber.New()
+	app.Use(Middleware(sender, nil))
+
+The middleware sets the same event fields as apinalytics_client.Wrap, except that Function is the path of the route
+that matched the request, e.g. "/users/:id", and ErrorMessage and ErrorType describe the error the handlers
+returned, if any.
+
+To add your own data to the events reported add a callback.  It is called after the rest of the handler chain, so
+can use anything your authentication middleware stored in the context.
+
+	callback := func(c *fiber.Ctx, event *apinalytics_client.AnalyticsEvent) {
+		event.ConsumerId = c.Locals("api_user").(string)
+	}
+
+	app.Use(Middleware(sender, callback))
+
+The callback must not keep c, or strings from it, beyond the call.  Options from the apinalytics_client package, e.g.
+WithRequestSampleRate or WithClientIP, can be added after the callback.  A callback added with WithRequestCallback
+is called before callback.
+*/
+func Middleware(sender cli.Queuer,
+	callback func(c *gofiber.Ctx, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) gofiber.Handler {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(c *gofiber.Ctx) error {
+		if !config.Paths.Allow(c.Path()) {
+			return c.Next()
+		}
+		start := config.Now()
+
+		err := c.Next()
+
+		// Errors are turned into responses by the app's error handler after we return, so work out the status it
+		// will most likely send
+		ww := &cli.StatusTrackingResponseWriter{
+			Status: c.Response().StatusCode(),
+			Bytes:  apifasthttp.ResponseBytes(c.Response()),
+		}
+		if err != nil {
+			ww.Status = http.StatusInternalServerError
+			var fiberErr *gofiber.Error
+			if errors.As(err, &fiberErr) {
+				ww.Status = fiberErr.Code
+			}
+		}
+		// Fiber reuses its buffers once the handler returns, so NewRequest copies what we need
+		r := apifasthttp.NewRequest(c.Context())
+		event := config.NewEvent(r, start, ww)
+		event.Function = strings.Clone(c.Route().Path)
+		cli.SetError(event, err)
+		if config.Callback != nil {
+			config.Callback(event, r)
+		}
+		if callback != nil {
+			callback(c, event)
+		}
+		config.Report(event)
+		return err
+	}
+}
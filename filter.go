@@ -0,0 +1,38 @@
+package apinalytics_client
+
+/*
+Filter decides whether an event should be reported.  It returns false to discard the event.
+*/
+type Filter func(event *AnalyticsEvent) bool
+
+/*
+WithFilter makes Queue discard events that filter rejects, so health checks, OPTIONS requests or internal consumers
+can be dropped in one place rather than at every call site.
+
+	sender := NewSender(appId, key, url, WithFilter(func(event *AnalyticsEvent) bool {
+		return event.Method != "OPTIONS" && event.Url != "/healthz"
+	}))
+
+Filters run in the goroutine calling Queue, so should be quick.  If WithFilter is given more than once an event must
+pass every filter to be reported.
+*/
+func WithFilter(filter Filter) Option {
+	return func(sender *Sender) {
+		if filter != nil {
+			sender.filters = append(sender.filters, filter)
+		}
+	}
+}
+
+// Decide whether to keep an event according to the sender's filters
+func (sender *Sender) filter(event *AnalyticsEvent) bool {
+	if event == nil {
+		return true
+	}
+	for _, filter := range sender.filters {
+		if !filter(event) {
+			return false
+		}
+	}
+	return true
+}
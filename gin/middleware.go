@@ -0,0 +1,68 @@
+/*
+Package gin contains gin (https://github.com/gin-gonic/gin) middleware for reporting events to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package gin
+
+import (
+	cli "github.com/apinalytics/apinalytics_client"
+	gogin "github.com/gin-gonic/gin"
+)
+
+/*
+Middleware builds gin middleware that reports HTTP requests to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	r := gin.New()
+	r.Use(Middleware(sender, nil))
+
+The middleware sets the same event fields as apinalytics_client.Wrap, except that Function is the route that matched
+the request, as returned by FullPath, e.g. "/users/:id", or "unknown" if no route matched.
+
+To add your own data to the events reported add a callback.  It is called after the rest of the handler chain, so
+can use anything your authentication middleware stored in the context.
+
+	callback := func(c *gin.Context, event *apinalytics_client.AnalyticsEvent) {
+		event.ConsumerId = c.GetString("api_user")
+	}
+
+	r.Use(Middleware(sender, callback))
+
+Options from the apinalytics_client package, e.g. WithRequestSampleRate, WithExcludedPaths or WithClientIP, can be
+added after the callback.  A callback added with WithRequestCallback is called before callback.
+*/
+func Middleware(sender cli.Queuer,
+	callback func(c *gogin.Context, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) gogin.HandlerFunc {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(c *gogin.Context) {
+		if !config.Paths.Allow(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		start := config.Now()
+		c.Request = cli.RecordErrors(c.Request)
+
+		c.Next()
+
+		ww := &cli.StatusTrackingResponseWriter{Status: c.Writer.Status()}
+		if size := c.Writer.Size(); size > 0 {
+			ww.Bytes = int64(size)
+		}
+		event := config.NewEvent(c.Request, start, ww)
+		if function := c.FullPath(); function != "" {
+			event.Function = function
+		}
+		if config.Callback != nil {
+			config.Callback(event, c.Request)
+		}
+		if callback != nil {
+			callback(c, event)
+		}
+		config.Report(event)
+	}
+}
@@ -7,7 +7,6 @@ package goji
 
 import (
 	"net/http"
-	"time"
 
 	cli "github.com/apinalytics/apinalytics_client"
 	"github.com/zenazn/goji/web"
@@ -21,7 +20,7 @@ Add it to your Goji mux m as follows.
     m.Use(BuildMiddleWare(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/", nil))
 
 To add your own data to the events reported add a callback. The main use for this at the moment is to
-record the ID of the API consumer.
+record the ID of the API consumer, though WithConsumerID covers the common cases.
 
     callback := func(c *web.C, event *apinalytics_client.AnalyticsEvent, r *http.Request) {
         event.ConsumerId = c.Env["api_user"].(string)
@@ -29,7 +28,9 @@ record the ID of the API consumer.
 
     m.Use(BuildMiddleWare(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/", callback))
 
-The middleware sets the following event fields: Timestamp, Method, Url, ResponseUS, StatusCode.  It will
+The middleware sets the following event fields: Timestamp, Method, Url, Path, Query, UserAgent, ResponseUS,
+StatusCode, RequestBytes, ResponseBytes, and TraceId and SpanId if the request has a traceparent header, and
+ErrorMessage and ErrorType if the handler calls apinalytics_client.RecordError.  It will
 also set Function if you record the name of the endpoint/method handling function in c.Env["function"] - e.g.
 if you have a function GetEvent that handles GET /api/1/event/:itemtype/ you might record the function name as
 follows.
@@ -41,45 +42,129 @@ follows.
     :
  }
 
+Otherwise Function is "unknown".  To work Function out some other way, e.g. from the name of the handler, use
+WithFunctionResolver.
+
+If a handler panics the middleware reports the request as a 500, with Data["panic"] set to "true", then panics again
+so that Goji's Recoverer or your own recovery middleware can deal with it.  Use WithSwallowPanics to have the
+middleware respond with a 500 itself instead.
+
+Options can be added after the callback, e.g. to sample events reported through this middleware.
+
+    m.Use(BuildMiddleWare(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/", nil, WithSampleRate(0.1)))
+
+BuildMiddleWare is shorthand for NewMiddleware, which takes all its settings in a Config.
+
 */
 func BuildMiddleWare(applicationId, writeKey, url string,
 	callback func(c *web.C, event *cli.AnalyticsEvent, r *http.Request),
+	options ...Option,
 ) func(c *web.C, h http.Handler) http.Handler {
-	sender := cli.NewSender(applicationId, writeKey, url)
+	config := Config{Callback: callback}
+	config.ApplicationId, config.WriteKey, config.URL = applicationId, writeKey, url
+	for _, option := range options {
+		option(&config)
+	}
+	return NewMiddleware(config)
+}
 
-	// Return the middleware that references the analytics queue we just made
-	return func(c *web.C, h http.Handler) http.Handler {
-		handler := func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-			ww := &cli.StatusTrackingResponseWriter{w, http.StatusOK}
+/*
+BuildMiddlewareWithSender builds middleware for Goji like BuildMiddleWare, but reporting to sender rather than a Sender
+of its own.  Use it to share one Sender, with one queue and flush policy, between several muxes, and to Queue events
+of your own alongside theirs.
 
-			h.ServeHTTP(ww, r)
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	api.Use(BuildMiddlewareWithSender(sender, WithCallback(callback)))
+	admin.Use(BuildMiddlewareWithSender(sender, WithExcludedPaths("/admin/healthz")))
+*/
+func BuildMiddlewareWithSender(sender cli.Queuer, options ...Option) func(c *web.C, h http.Handler) http.Handler {
+	var config Config
+	for _, option := range options {
+		option(&config)
+	}
+	config.Sender = sender
+	return NewMiddleware(config)
+}
 
-			var function string
-			if ff, ok := c.Env["function"]; ok && ff != nil {
-				function = ff.(string)
-			}
+/*
+Config holds everything the middleware needs to know.  The embedded apinalytics_client.MiddlewareConfig has the
+settings shared with the rest of the apinalytics middleware: the Sender, or the credentials to create one, filters,
+samplers and so on.
 
-			if function == "" {
-				function = "unknown"
-			}
-			event := &cli.AnalyticsEvent{
-				Timestamp:  time.Now().Unix(),
-				Method:     r.Method,
-				Url:        r.RequestURI,
-				Function:   function,
-				ResponseUS: int(time.Since(start).Nanoseconds() / 1000),
-				StatusCode: ww.Status,
-			}
-			// "path":        r.URL.Path,
-			// "user_agent":  r.UserAgent(),
-			// "header":      r.Header,
-			// Get more data for the analytics event
-			if callback != nil {
-				callback(c, event, r)
-			}
+	config := Config{Resolvers: []FunctionResolver{EnvFunction, RoutePattern}}
+	config.Sender = sender
+	m.Use(NewMiddleware(config))
+*/
+type Config struct {
+	cli.MiddlewareConfig
+	// Given each event before it is queued, along with the Goji context and request.  Called after the embedded
+	// MiddlewareConfig's Callback, if both are set
+	Callback func(c *web.C, event *cli.AnalyticsEvent, r *http.Request)
+	// Work out Function, tried in order.  Nil for just EnvFunction.  See WithFunctionResolver
+	Resolvers []FunctionResolver
+	// Recover from panics rather than passing them on.  See WithSwallowPanics
+	SwallowPanics bool
+}
 
-			sender.Queue(event)
+/*
+NewMiddleware builds middleware for Goji from config.  See BuildMiddleWare for what it reports.
+*/
+func NewMiddleware(config Config) func(c *web.C, h http.Handler) http.Handler {
+	if config.Resolvers == nil {
+		config.Resolvers = []FunctionResolver{EnvFunction}
+	}
+	if config.Sender == nil {
+		config.Sender = cli.NewSender(config.ApplicationId, config.WriteKey, config.URL, config.SenderOptions...)
+	}
+
+	// Return the middleware that references the analytics queue we just made
+	return func(c *web.C, h http.Handler) http.Handler {
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			if !config.Paths.Allow(r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			start := config.Now()
+			tw, ww := cli.TrackResponse(w)
+			r = cli.RecordErrors(r)
+			panicked := true
+
+			// Requests that panic are reported too, as 500s.  Reporting from a deferred function means that if we
+			// pass the panic on its stack trace still shows where it started
+			defer func() {
+				var rec interface{}
+				if panicked {
+					rec = recover()
+					ww.Status = http.StatusInternalServerError
+				}
+
+				event := config.NewEvent(r, start, ww)
+				event.Function = config.function(c, r)
+				if panicked {
+					if event.Data == nil {
+						event.Data = make(map[string]interface{}, 1)
+					}
+					event.Data["panic"] = "true"
+				}
+				// Get more data for the analytics event
+				if config.MiddlewareConfig.Callback != nil {
+					config.MiddlewareConfig.Callback(event, r)
+				}
+				if config.Callback != nil {
+					config.Callback(c, event, r)
+				}
+				config.Report(event)
+
+				if panicked {
+					if !config.SwallowPanics {
+						panic(rec)
+					}
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			h.ServeHTTP(tw, r)
+			panicked = false
 		}
 		return http.HandlerFunc(handler)
 	}
@@ -0,0 +1,177 @@
+package goji
+
+import (
+	"net/http"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/zenazn/goji/web"
+)
+
+// Option configures optional behaviour of the middleware built by BuildMiddleWare.
+type Option func(*Config)
+
+/*
+WithMiddlewareOptions applies options from the apinalytics_client package, e.g. WithErrorsOnly, to the middleware.
+Most of them also have an equivalent in this package.
+*/
+func WithMiddlewareOptions(options ...cli.MiddlewareOption) Option {
+	return func(c *Config) {
+		c.Apply(options...)
+	}
+}
+
+/*
+WithCallback sets the callback given each event before it is queued, as passed to BuildMiddleWare.
+*/
+func WithCallback(callback func(c *web.C, event *cli.AnalyticsEvent, r *http.Request)) Option {
+	return func(c *Config) {
+		c.Callback = callback
+	}
+}
+
+/*
+WithSenderOptions passes options through to the Sender the middleware creates, e.g. to set a flush interval or
+retry policy.
+*/
+func WithSenderOptions(options ...cli.Option) Option {
+	return func(c *Config) {
+		c.SenderOptions = append(c.SenderOptions, options...)
+	}
+}
+
+/*
+WithSampleRate reports only a fraction of the requests handled by this middleware, overriding any sample rate set on
+the Sender.  See apinalytics_client.WithSampleRate.
+*/
+func WithSampleRate(rate float64) Option {
+	return func(c *Config) {
+		c.SampleRate = rate
+	}
+}
+
+/*
+WithSender makes the middleware queue events to sender rather than creating a Sender of its own, e.g. to share a
+Sender between several muxes, or to substitute a fake in tests.  The application ID, write key, URL and any
+WithSenderOptions passed to BuildMiddleWare are ignored.  See also BuildMiddlewareWithSender.
+*/
+func WithSender(sender cli.Queuer) Option {
+	return func(c *Config) {
+		c.Sender = sender
+	}
+}
+
+/*
+WithClock makes the middleware use clock rather than the time package to timestamp events and time requests, so tests
+can check the Timestamp and ResponseUS fields.
+*/
+func WithClock(clock cli.Clock) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+/*
+WithSwallowPanics makes the middleware recover from panics in the handlers it wraps, responding with a 500, rather
+than reporting the request and panicking again.
+*/
+func WithSwallowPanics() Option {
+	return func(c *Config) {
+		c.SwallowPanics = true
+	}
+}
+
+/*
+WithExcludedPaths stops the middleware reporting requests for the given paths, e.g. "/healthz", "/metrics" and
+"/favicon.ico".  Paths ending in * are prefixes.  See apinalytics_client.PathRules.
+*/
+func WithExcludedPaths(paths ...string) Option {
+	return WithMiddlewareOptions(cli.WithExcludedPaths(paths...))
+}
+
+/*
+WithIncludedPaths makes the middleware report only requests for the given paths, e.g. "/api/*".  Paths ending in *
+are prefixes.  See apinalytics_client.PathRules.
+*/
+func WithIncludedPaths(paths ...string) Option {
+	return WithMiddlewareOptions(cli.WithIncludedPaths(paths...))
+}
+
+/*
+WithErrorsOnly makes the middleware report only responses with status 400 and above.
+*/
+func WithErrorsOnly() Option {
+	return WithMiddlewareOptions(cli.WithErrorsOnly())
+}
+
+/*
+WithSuccessSampleRate makes the middleware report only a fraction rate of responses with status below 400, while
+always reporting those with status 400 and above.  See apinalytics_client.StatusSampling.
+*/
+func WithSuccessSampleRate(rate float64) Option {
+	return WithMiddlewareOptions(cli.WithSuccessSampleRate(rate))
+}
+
+/*
+CaptureHeaders makes the middleware copy the named request headers into each event's Data, e.g.
+Data["header.User-Agent"].  See apinalytics_client.CaptureHeaders.
+*/
+func CaptureHeaders(names ...string) Option {
+	return WithMiddlewareOptions(cli.CaptureHeaders(names...))
+}
+
+/*
+WithQueryMode sets what the middleware does with query strings, e.g. apinalytics_client.QueryDrop to keep them out of
+apinalytics entirely.
+*/
+func WithQueryMode(mode cli.QueryMode) Option {
+	return WithMiddlewareOptions(cli.WithQueryMode(mode))
+}
+
+/*
+WithClientIP makes the middleware set each event's ClientIP using resolver.  See
+apinalytics_client.ClientIPResolver.
+*/
+func WithClientIP(resolver cli.ClientIPResolver) Option {
+	return WithMiddlewareOptions(cli.WithClientIP(resolver))
+}
+
+/*
+WithUserAgentParser makes the middleware classify each request's User-Agent with parser, e.g.
+apinalytics_client.ParseUserAgent.  See apinalytics_client.WithUserAgentParser.
+*/
+func WithUserAgentParser(parser cli.UserAgentParser) Option {
+	return WithMiddlewareOptions(cli.WithUserAgentParser(parser))
+}
+
+/*
+WithRequestID makes the middleware set each event's RequestId from the first of the named request headers that is
+present.  With no names it uses apinalytics_client.DefaultRequestIDHeaders.
+*/
+func WithRequestID(names ...string) Option {
+	return WithMiddlewareOptions(cli.WithRequestID(names...))
+}
+
+/*
+WithConsumerID makes the middleware set each event's ConsumerId using the first of extractors that finds one, e.g.
+apinalytics_client.FromHeader("X-Api-Key").  The callback can still override it.
+*/
+func WithConsumerID(extractors ...cli.ConsumerExtractor) Option {
+	return WithMiddlewareOptions(cli.WithConsumerID(extractors...))
+}
+
+/*
+WithRouteTags makes the middleware add tags, e.g. "team": "payments", to the Data of events for requests whose path
+matches path.  See apinalytics_client.WithRouteTags.
+*/
+func WithRouteTags(path string, tags map[string]string) Option {
+	return WithMiddlewareOptions(cli.WithRouteTags(path, tags))
+}
+
+/*
+WithApdex makes the middleware rate each request against the Apdex threshold, e.g. 300 * time.Millisecond, in
+Data["apdex"].  See apinalytics_client.WithApdex.
+*/
+func WithApdex(threshold time.Duration) Option {
+	return WithMiddlewareOptions(cli.WithApdex(threshold))
+}
@@ -0,0 +1,131 @@
+package goji
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/zenazn/goji/web"
+)
+
+/*
+FunctionResolver works out the Function to report for a request, once it has been handled.  It returns "" if it
+can't tell.
+*/
+type FunctionResolver func(c *web.C, r *http.Request) string
+
+/*
+EnvFunction resolves Function from c.Env["function"], which your handlers can set to the name of the function handling
+the request.  The value can be a string or a fmt.Stringer.  This is the default resolver.
+*/
+func EnvFunction(c *web.C, r *http.Request) string {
+	if c == nil || c.Env == nil {
+		return ""
+	}
+	switch function := c.Env["function"].(type) {
+	case string:
+		return function
+	case fmt.Stringer:
+		return function.String()
+	}
+	return ""
+}
+
+/*
+HandlerName resolves Function from the name of the handler the request was routed to, e.g. "api.GetEvent" for a
+function GetEvent in package api, or the type of handler for handlers that aren't functions.  The middleware can only
+see which handler was chosen if routing happens in the middleware stack, so add Goji's router as middleware after
+this middleware.
+
+	m.Use(BuildMiddleWare(myAppId, myWriteKey, url, nil, WithFunctionResolver(HandlerName)))
+	m.Use(m.Router)
+*/
+func HandlerName(c *web.C, r *http.Request) string {
+	if c == nil {
+		return ""
+	}
+	handler := web.GetMatch(*c).RawHandler()
+	if handler == nil {
+		return ""
+	}
+	var name string
+	if v := reflect.ValueOf(handler); v.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			name = fn.Name()
+		}
+	} else {
+		name = reflect.TypeOf(handler).String()
+	}
+	// Keep the package name but not the rest of the import path
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimPrefix(name, "*")
+}
+
+/*
+WithFunctionResolver sets how the middleware works out the Function to report for each request.  Resolvers are tried
+in order until one returns a name.  If none do Function is "unknown".  The default is EnvFunction.
+
+	WithFunctionResolver(EnvFunction, HandlerName)
+
+Any func(c *web.C, r *http.Request) string can be used as a resolver.
+*/
+func WithFunctionResolver(resolvers ...FunctionResolver) Option {
+	return func(c *Config) {
+		c.Resolvers = resolvers
+	}
+}
+
+// Work out the Function for a request
+func (config *Config) function(c *web.C, r *http.Request) string {
+	for _, resolve := range config.Resolvers {
+		if function := resolve(c, r); function != "" {
+			return function
+		}
+	}
+	return "unknown"
+}
+
+/*
+RoutePattern resolves Function from the pattern of the route the request matched, e.g. "/api/1/event/:itemtype/", so
+requests are grouped by endpoint without your handlers having to set c.Env["function"].  Like HandlerName it needs
+Goji's router added as middleware after this middleware.  Without that it falls back to rebuilding the pattern from
+the request's path and c.URLParams, replacing each parameter's value with its name.
+*/
+func RoutePattern(c *web.C, r *http.Request) string {
+	if c == nil {
+		return ""
+	}
+	switch pattern := web.GetMatch(*c).RawPattern().(type) {
+	case string:
+		return pattern
+	case fmt.Stringer:
+		// Includes regular expressions
+		return pattern.String()
+	}
+
+	if len(c.URLParams) == 0 {
+		return ""
+	}
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		for name, value := range c.URLParams {
+			if segment == value && segment != "" {
+				segments[i] = ":" + name
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+/*
+WithRoutePatterns reports the pattern of the route each request matched as its Function, unless the handler set
+c.Env["function"].  It is shorthand for WithFunctionResolver(EnvFunction, RoutePattern).
+*/
+func WithRoutePatterns() Option {
+	return WithFunctionResolver(EnvFunction, RoutePattern)
+}
@@ -0,0 +1,54 @@
+/*
+Package gojiio contains middleware for the modern Goji (https://goji.io) for reporting events to Apinalytics.  For
+the original github.com/zenazn/goji see the goji package.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package gojiio
+
+import (
+	"fmt"
+	"net/http"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"goji.io/middleware"
+)
+
+/*
+Middleware builds Goji middleware that reports HTTP requests to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	mux := goji.NewMux()
+	mux.Use(Middleware(sender, nil))
+
+Events have Function set to the pattern that matched the request, e.g. "/users/:id", if the pattern can describe
+itself as a string, as those from goji.io/pat can.  Otherwise, or if no pattern matched, Function is "unknown".
+
+To add your own data to the events reported add a callback, e.g. to record the ID of the API consumer.
+
+	callback := func(event *apinalytics_client.AnalyticsEvent, r *http.Request) {
+		event.ConsumerId = r.Context().Value(userKey).(string)
+	}
+
+	mux.Use(Middleware(sender, callback))
+
+Options from the apinalytics_client package, e.g. WithRequestSampleRate, can be added after the callback.  Pass your
+callback as callback rather than with WithRequestCallback, which would stop Function being set.
+*/
+func Middleware(sender cli.Queuer,
+	callback func(event *cli.AnalyticsEvent, r *http.Request),
+	options ...cli.MiddlewareOption,
+) func(http.Handler) http.Handler {
+	options = append([]cli.MiddlewareOption{cli.WithRequestCallback(func(event *cli.AnalyticsEvent, r *http.Request) {
+		if pattern, ok := middleware.Pattern(r.Context()).(fmt.Stringer); ok {
+			event.Function = pattern.String()
+		}
+		if callback != nil {
+			callback(event, r)
+		}
+	})}, options...)
+
+	return func(h http.Handler) http.Handler {
+		return cli.Wrap(h, sender, options...)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Package graphql contains a gqlgen (https://gqlgen.com) extension for reporting GraphQL operations to Apinalytics.
+Every GraphQL request goes to the same URL, so HTTP middleware can't tell operations apart; this reports each one by
+name instead.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
+	srv.Use(NewExtension(sender, nil))
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package graphql
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+Extension is a gqlgen handler extension that reports each GraphQL operation to Apinalytics.  Create one with
+NewExtension.
+
+Events have Method set to the operation type, e.g. "QUERY" or "MUTATION", and Function to the operation name, or
+"anonymous" for unnamed operations.  StatusCode is 200 if the response has no errors and 500 if it has any, with the
+number of errors in Data["errors"].  The time spent in each resolver, totalled across the operation, is reported in
+microseconds in Data, keyed by object and field, e.g. Data["resolver.Query.user"].  Subscriptions report each
+response separately.
+*/
+type Extension struct {
+	callback func(ctx context.Context, event *cli.AnalyticsEvent)
+	config   cli.MiddlewareConfig
+}
+
+/*
+NewExtension creates an Extension that reports operations to sender.  To add your own data to the events reported,
+e.g. the API consumer from your authentication middleware, add a callback.
+
+	callback := func(ctx context.Context, event *apinalytics_client.AnalyticsEvent) {
+		event.ConsumerId, _ = ctx.Value(userKey).(string)
+	}
+
+The extension takes options from the apinalytics_client package, e.g. WithRequestSampleRate, WithErrorsOnly or
+WithApdex.  Options that read headers, such as WithRequestID and CaptureHeaders, read those gqlgen passes on.  gqlgen
+doesn't give extensions the HTTP request itself, so WithRequestCallback and WithClientIP have no effect.  Path is
+"/graphql" for WithRouteTags.
+*/
+func NewExtension(sender cli.Queuer,
+	callback func(ctx context.Context, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) *Extension {
+	e := &Extension{callback: callback, config: cli.MiddlewareConfig{Sender: sender}}
+	e.config.Apply(options...)
+	return e
+}
+
+/*
+ExtensionName implements graphql.HandlerExtension.
+*/
+func (e *Extension) ExtensionName() string {
+	return "ApinalyticsExtension"
+}
+
+/*
+Validate implements graphql.HandlerExtension.
+*/
+func (e *Extension) Validate(schema gql.ExecutableSchema) error {
+	return nil
+}
+
+/*
+InterceptResponse implements graphql.ResponseInterceptor.  It reports the operation once the response is ready.
+*/
+func (e *Extension) InterceptResponse(ctx context.Context, next gql.ResponseHandler) *gql.Response {
+	if !gql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	start := e.config.Now()
+	timings := &resolverTimings{totals: make(map[string]time.Duration)}
+
+	rsp := next(context.WithValue(ctx, timingsKey{}, timings))
+
+	oc := gql.GetOperationContext(ctx)
+	event := &cli.AnalyticsEvent{
+		Method:     "QUERY",
+		Url:        "/graphql",
+		Path:       "/graphql",
+		Function:   oc.OperationName,
+		StatusCode: 200,
+		Data:       timings.data(),
+	}
+	if oc.Operation != nil {
+		event.Method = strings.ToUpper(string(oc.Operation.Operation))
+	}
+	if event.Function == "" {
+		event.Function = "anonymous"
+	}
+	if rsp != nil && len(rsp.Errors) > 0 {
+		event.StatusCode = 500
+		event.Data["errors"] = len(rsp.Errors)
+	}
+	e.config.SetHeaders(event, oc.Headers)
+	e.config.Finish(event, start)
+	if e.callback != nil {
+		e.callback(ctx, event)
+	}
+	e.config.Report(event)
+	return rsp
+}
+
+/*
+InterceptField implements graphql.FieldInterceptor.  It times fields that have resolvers.
+*/
+func (e *Extension) InterceptField(ctx context.Context, next gql.Resolver) (interface{}, error) {
+	fc := gql.GetFieldContext(ctx)
+	timings, ok := ctx.Value(timingsKey{}).(*resolverTimings)
+	if !ok || fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	start := e.config.Now()
+	res, err := next(ctx)
+	timings.add("resolver."+fc.Object+"."+fc.Field.Name, e.config.Now().Sub(start))
+	return res, err
+}
+
+// The context key for an operation's resolverTimings
+type timingsKey struct{}
+
+// Time spent in each resolver during an operation.  Resolvers may run concurrently
+type resolverTimings struct {
+	lock   sync.Mutex
+	totals map[string]time.Duration
+}
+
+func (t *resolverTimings) add(key string, d time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.totals[key] += d
+}
+
+// The timings as event Data, in microseconds
+func (t *resolverTimings) data() map[string]interface{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	data := make(map[string]interface{}, len(t.totals)+1)
+	for key, d := range t.totals {
+		data[key] = d.Nanoseconds() / 1000
+	}
+	return data
+}
+
+var (
+	_ gql.HandlerExtension    = (*Extension)(nil)
+	_ gql.ResponseInterceptor = (*Extension)(nil)
+	_ gql.FieldInterceptor    = (*Extension)(nil)
+)
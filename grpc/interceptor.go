@@ -0,0 +1,208 @@
+/*
+Package grpc contains gRPC (https://grpc.io) server interceptors for reporting RPCs to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(sender, nil)),
+		grpc.StreamInterceptor(StreamServerInterceptor(sender, nil)),
+	)
+
+Events have Method "POST", as gRPC calls are HTTP/2 POSTs, and both Url and Function set to the full method name,
+e.g. "/helloworld.Greeter/SayHello".  StatusCode is the HTTP status closest to the call's gRPC status code, which is
+also reported in Data["grpc_code"].
+
+To add your own data to the events reported add a callback, e.g. to record the API consumer from the call's metadata.
+
+	callback := func(ctx context.Context, event *apinalytics_client.AnalyticsEvent) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["api-user"]) > 0 {
+			event.ConsumerId = md["api-user"][0]
+		}
+	}
+
+The interceptors take options from the apinalytics_client package, e.g. WithRequestSampleRate, WithRequestID or
+WithConsumerID, which see the call's metadata as request headers and its full method name as the path, so
+WithExcludedPaths("/grpc.health.v1.Health/*") stops health checks being reported.  ErrorMessage and ErrorType describe
+the error the handler returned, if any.  WithRequestCallback callbacks are given a request made up in the same way,
+and are called before callback.
+*/
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+/*
+UnaryServerInterceptor returns an interceptor that reports each unary RPC to sender once it completes.
+*/
+func UnaryServerInterceptor(sender cli.Queuer,
+	callback func(ctx context.Context, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) gogrpc.UnaryServerInterceptor {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+		if !config.Paths.Allow(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := config.Now()
+
+		rsp, err := handler(ctx, req)
+
+		event, r := newEvent(ctx, &config, info.FullMethod, start, err)
+		if config.Callback != nil {
+			config.Callback(event, r)
+		}
+		if callback != nil {
+			callback(ctx, event)
+		}
+		config.Report(event)
+		return rsp, err
+	}
+}
+
+/*
+StreamServerInterceptor returns an interceptor that reports each streaming RPC to sender when the stream closes.
+ResponseUS is how long the stream was open, and the numbers of messages received from and sent to the client are
+reported in Data["messages_received"] and Data["messages_sent"].
+*/
+func StreamServerInterceptor(sender cli.Queuer,
+	callback func(ctx context.Context, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) gogrpc.StreamServerInterceptor {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(srv interface{}, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+		if !config.Paths.Allow(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := config.Now()
+		stream := &countingStream{ServerStream: ss}
+
+		err := handler(srv, stream)
+
+		event, r := newEvent(ss.Context(), &config, info.FullMethod, start, err)
+		event.Data["messages_received"] = atomic.LoadInt64(&stream.received)
+		event.Data["messages_sent"] = atomic.LoadInt64(&stream.sent)
+		if config.Callback != nil {
+			config.Callback(event, r)
+		}
+		if callback != nil {
+			callback(ss.Context(), event)
+		}
+		config.Report(event)
+		return err
+	}
+}
+
+// Build the event for a call to method that started at start and finished with err, along with the request it was
+// built from
+func newEvent(ctx context.Context, config *cli.MiddlewareConfig, method string, start time.Time, err error) (*cli.AnalyticsEvent, *http.Request) {
+	code := status.Code(err)
+	r := request(ctx, method)
+	event := config.NewEvent(r, start, &cli.StatusTrackingResponseWriter{Status: httpStatus(code)})
+	event.Function = method
+	if event.Data == nil {
+		event.Data = make(map[string]interface{})
+	}
+	event.Data["grpc_code"] = code.String()
+	cli.SetError(event, err)
+	return event, r
+}
+
+// Make up an *http.Request describing a call to method, with the call's metadata as its headers, so the middleware
+// settings in apinalytics_client can be applied to it.  gRPC calls are HTTP/2 POSTs to the method's full name
+func request(ctx context.Context, method string) *http.Request {
+	r := &http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: method},
+		RequestURI: method,
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     make(http.Header),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			// Skip pseudo-headers and binary values
+			if strings.HasPrefix(key, ":") || strings.HasSuffix(key, "-bin") {
+				continue
+			}
+			for _, value := range values {
+				r.Header.Add(key, value)
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+	return r.WithContext(ctx)
+}
+
+// A ServerStream that counts the messages passing through it.  Handlers may send and receive from different
+// goroutines, so the counts are updated atomically
+type countingStream struct {
+	gogrpc.ServerStream
+	received int64
+	sent     int64
+}
+
+func (s *countingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.received, 1)
+	}
+	return err
+}
+
+func (s *countingStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.sent, 1)
+	}
+	return err
+}
+
+// The HTTP status closest to a gRPC status code, as used by grpc-gateway
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
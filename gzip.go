@@ -0,0 +1,29 @@
+package apinalytics_client
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+/*
+WithGzip makes the sender gzip the body of each POST and mark it with Content-Encoding: gzip.  Batches of events
+compress well, so this cuts outbound bandwidth considerably at the cost of a little CPU in the background goroutine.
+*/
+func WithGzip() Option {
+	return func(sender *Sender) {
+		sender.gzip = true
+	}
+}
+
+// Gzip data
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
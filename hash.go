@@ -0,0 +1,34 @@
+package apinalytics_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+/*
+WithConsumerIDHashing makes the sender replace each event's ConsumerId with HashConsumerID(ConsumerId, salt), so you
+can still count and tell apart consumers without API keys, emails or other identifying IDs reaching apinalytics.
+Hashing happens in the background goroutine after any enrichers have run, so IDs they set are hashed too.
+
+Keep salt secret and don't change it, or the same consumer will get a different ID.
+*/
+func WithConsumerIDHashing(salt string) Option {
+	return func(sender *Sender) {
+		sender.hashConsumer = func(id string) string {
+			return HashConsumerID(id, salt)
+		}
+	}
+}
+
+/*
+HashConsumerID returns the hex encoded SHA-256 hash of salt followed by id, or "" if id is "".
+*/
+func HashConsumerID(id, salt string) string {
+	if id == "" {
+		return ""
+	}
+	hash := sha256.New()
+	hash.Write([]byte(salt))
+	hash.Write([]byte(id))
+	return hex.EncodeToString(hash.Sum(nil))
+}
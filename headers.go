@@ -0,0 +1,45 @@
+package apinalytics_client
+
+import (
+	"net/http"
+)
+
+/*
+WithHeaders adds headers to every POST to apinalytics, e.g. a tenant ID or environment name.  Headers the sender sets
+itself, such as Content-Type and X-Auth-User, can't be replaced.
+*/
+func WithHeaders(headers map[string]string) Option {
+	return func(sender *Sender) {
+		if sender.headers == nil {
+			sender.headers = make(http.Header, len(headers))
+		}
+		for name, value := range headers {
+			sender.headers.Set(name, value)
+		}
+	}
+}
+
+/*
+WithHeaderProvider calls provider before every POST to apinalytics and adds the headers it returns, for values that
+change over time such as short-lived tokens.  Headers from a provider replace those with the same name given to
+WithHeaders.  provider is called from the sender's background goroutine.
+*/
+func WithHeaderProvider(provider func() http.Header) Option {
+	return func(sender *Sender) {
+		if provider != nil {
+			sender.headerFuncs = append(sender.headerFuncs, provider)
+		}
+	}
+}
+
+// Add the extra headers configured for the sender
+func (sender *Sender) addHeaders(header http.Header) {
+	for name, values := range sender.headers {
+		header[name] = append([]string(nil), values...)
+	}
+	for _, provider := range sender.headerFuncs {
+		for name, values := range provider() {
+			header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+WithHeartbeat makes the sender queue a heartbeat event every interval, so apinalytics can tell a service with no
+traffic from one whose instrumentation is broken or whose host is down.  Heartbeats have Kind KindHeartbeat,
+Function "heartbeat", the sender's EventDefaults such as Hostname and ServiceName, and its Stats in Data: "queued",
+"events_sent", "batches_sent", "send_failures" and "dropped", along with "uptime_s", the seconds since the sender
+was created.
+
+Heartbeats bypass filters and sampling.  If the queue is full the heartbeat is dropped rather than waiting.
+*/
+func WithHeartbeat(interval time.Duration) Option {
+	return func(sender *Sender) {
+		sender.heartbeat = interval
+	}
+}
+
+// Queue a heartbeat every interval until the sender is closed
+func (sender *Sender) beat() {
+	started := sender.clock.Now()
+	ticker := sender.clock.NewTicker(sender.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sender.quit:
+			return
+		case now := <-ticker.C():
+			select {
+			case sender.channel <- sender.heartbeatEvent(now, now.Sub(started)):
+			default:
+				sender.drop()
+			}
+		}
+	}
+}
+
+// Build a heartbeat event
+func (sender *Sender) heartbeatEvent(now time.Time, uptime time.Duration) *AnalyticsEvent {
+	stats := sender.Stats()
+	event := &AnalyticsEvent{
+		Kind:     KindHeartbeat,
+		Function: "heartbeat",
+		Data: map[string]interface{}{
+			"queued":        stats.Queued,
+			"events_sent":   stats.EventsSent,
+			"batches_sent":  stats.BatchesSent,
+			"send_failures": stats.SendFailures,
+			"dropped":       stats.Dropped,
+			"uptime_s":      int64(uptime / time.Second),
+		},
+	}
+	event.SetTime(now)
+	return event
+}
@@ -0,0 +1,48 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+OnBatchSent registers fn to be called after each batch is successfully posted to apinalytics, with the batch's ID,
+the number of events in it and how long the POST took.  fn is called from the sender's background goroutine, so must
+be quick.  Hooks accumulate: if OnBatchSent is given more than once, e.g. by an integration such as otel.WithTracing,
+each fn is called, in the order given.
+*/
+func OnBatchSent(fn func(batchID string, count int, took time.Duration)) Option {
+	return func(sender *Sender) {
+		previous := sender.batchSent
+		if previous == nil {
+			sender.batchSent = fn
+			return
+		}
+		sender.batchSent = func(batchID string, count int, took time.Duration) {
+			previous(batchID, count, took)
+			fn(batchID, count, took)
+		}
+	}
+}
+
+type batchSentHook func(batchID string, count int, took time.Duration)
+
+/*
+OnBatchFailed registers fn to be called each time a batch can't be delivered, with the error and the number of events
+in the batch.  It's called once retries are exhausted, after the error handler and before any dead letter hook.
+Like OnBatchSent, fn is called from the sender's background goroutine, so must be quick, and hooks accumulate.
+*/
+func OnBatchFailed(fn func(err error, count int)) Option {
+	return func(sender *Sender) {
+		previous := sender.batchFailed
+		if previous == nil {
+			sender.batchFailed = fn
+			return
+		}
+		sender.batchFailed = func(err error, count int) {
+			previous(err, count)
+			fn(err, count)
+		}
+	}
+}
+
+type batchFailedHook func(err error, count int)
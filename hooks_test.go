@@ -0,0 +1,35 @@
+package apinalytics_client_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+func TestBatchHooksAccumulate(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	var lock sync.Mutex
+	var calls []string
+	hook := func(name string) func(batchID string, count int, took time.Duration) {
+		return func(batchID string, count int, took time.Duration) {
+			lock.Lock()
+			defer lock.Unlock()
+			calls = append(calls, name)
+		}
+	}
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithFlushInterval(time.Hour),
+		cli.OnBatchSent(hook("first")), cli.OnBatchSent(hook("second")))
+
+	sender.Queue(&cli.AnalyticsEvent{Method: "GET", Url: "/a", StatusCode: 200})
+	sender.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Errorf("Expected both hooks called in order, got %v", calls)
+	}
+}
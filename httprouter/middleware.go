@@ -0,0 +1,100 @@
+/*
+Package httprouter contains a wrapper for httprouter (https://github.com/julienschmidt/httprouter) handlers that
+reports events to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package httprouter
+
+import (
+	"context"
+	"net/http"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	hr "github.com/julienschmidt/httprouter"
+)
+
+/*
+Wrap wraps the httprouter handler for route so that each request it handles is reported to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	router := httprouter.New()
+	router.GET("/users/:id", Wrap("/users/:id", GetUser, sender, nil))
+
+The wrapper sets the same event fields as apinalytics_client.Wrap, except that Function is route, so requests are
+grouped by route rather than by URL.  httprouter doesn't tell handlers which route matched, which is why it must be
+passed in.  The route's parameters aren't reported unless you ask for them with WithParams, as they are often IDs,
+email addresses or tokens.
+
+To add your own data to the events reported add a callback, which is also given the route parameters, so you can
+report those that are safe to.
+
+	callback := func(event *apinalytics_client.AnalyticsEvent, r *http.Request, ps httprouter.Params) {
+		event.ConsumerId = r.Header.Get("X-Api-User")
+		event.Set("format", ps.ByName("format"))
+	}
+
+Options from the apinalytics_client package, e.g. WithRequestSampleRate or WithClientIP, can be added after the
+callback.  A callback added with WithRequestCallback is called before callback, and can get the route parameters
+with httprouter.ParamsFromContext.
+*/
+func Wrap(route string, h hr.Handle, sender cli.Queuer,
+	callback func(event *cli.AnalyticsEvent, r *http.Request, ps hr.Params),
+	options ...cli.MiddlewareOption,
+) hr.Handle {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return func(w http.ResponseWriter, r *http.Request, ps hr.Params) {
+		if !config.Paths.Allow(r.URL.Path) {
+			h(w, r, ps)
+			return
+		}
+		start := config.Now()
+		tw, ww := cli.TrackResponse(w)
+		r = cli.RecordErrors(r)
+
+		h(tw, r, ps)
+
+		event := config.NewEvent(r, start, ww)
+		event.Function = route
+		if len(ps) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), hr.ParamsKey, ps))
+		}
+		if config.Callback != nil {
+			config.Callback(event, r)
+		}
+		if callback != nil {
+			callback(event, r, ps)
+		}
+		config.Report(event)
+	}
+}
+
+/*
+WithParams makes the wrapper copy the named route parameters into each event's Data, e.g. Data["param.format"], for
+parameters that are safe to report.  Parameters that aren't in the route matched are left out.
+
+	router.GET("/reports/:id/:format", Wrap("/reports/:id/:format", GetReport, sender, nil, WithParams("format")))
+
+It works through the WithRequestCallback callback, calling any set before it, so add any WithRequestCallback first.
+*/
+func WithParams(names ...string) cli.MiddlewareOption {
+	return func(c *cli.MiddlewareConfig) {
+		next := c.Callback
+		c.Callback = func(event *cli.AnalyticsEvent, r *http.Request) {
+			ps := hr.ParamsFromContext(r.Context())
+			for _, name := range names {
+				for _, p := range ps {
+					if p.Key == name {
+						event.Set("param."+name, p.Value)
+						break
+					}
+				}
+			}
+			if next != nil {
+				next(event, r)
+			}
+		}
+	}
+}
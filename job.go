@@ -0,0 +1,77 @@
+package apinalytics_client
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+JobEvent describes a run of a background job, such as a cron job or a queue worker processing a message, for reporting
+to apinalytics alongside your API traffic.  TrackJob fills one in for you; build your own if your jobs don't fit
+TrackJob's shape.
+*/
+type JobEvent struct {
+	// Name of the job
+	Name string
+	// When the job started
+	Start time.Time
+	// How long the job ran for
+	Duration time.Duration
+	// Error the job failed with.  nil if it succeeded
+	Err error
+	// True if the job panicked
+	Panicked bool
+}
+
+/*
+Event converts the job run to an AnalyticsEvent.  The event has Kind KindJob, Method "JOB", Function set to the job's
+name, ResponseUS to its duration and StatusCode to 200 if it succeeded or 500 if it failed.  Failures have the error
+in ErrorMessage and ErrorType, and panics also set Data["panic"] to "true".
+*/
+func (job *JobEvent) Event() *AnalyticsEvent {
+	end := job.Start.Add(job.Duration)
+	event := &AnalyticsEvent{
+		Kind:       KindJob,
+		Timestamp:  end.Unix(),
+		Time:       end,
+		Method:     "JOB",
+		Function:   job.Name,
+		ResponseUS: int(job.Duration.Nanoseconds() / 1000),
+		StatusCode: 200,
+	}
+	if job.Err != nil {
+		event.StatusCode = 500
+		SetError(event, job.Err)
+		if job.Panicked {
+			event.set("panic", "true")
+		}
+	}
+	return event
+}
+
+/*
+TrackJob runs fn, reports the run to sender as a job called name, and returns fn's error.  If fn panics the panic is
+recovered, reported, and returned as an error, so one bad run doesn't take down a worker.  If sender is a *Sender the
+run is timed with its clock, as set by WithClock.
+
+	err := TrackJob(sender, "nightly-report", func() error {
+		return buildReport(ctx)
+	})
+*/
+func TrackJob(sender Queuer, name string, fn func() error) (err error) {
+	now := time.Now
+	if s, ok := sender.(*Sender); ok {
+		now = s.clock.Now
+	}
+	job := &JobEvent{Name: name, Start: now()}
+	defer func() {
+		if r := recover(); r != nil {
+			job.Panicked = true
+			err = fmt.Errorf("apinalytics: job %s panicked: %v", name, r)
+		}
+		job.Duration = now().Sub(job.Start)
+		job.Err = err
+		sender.Queue(job.Event())
+	}()
+	return fn()
+}
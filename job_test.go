@@ -0,0 +1,44 @@
+package apinalytics_client_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+func TestTrackJobUsesSenderClock(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithFlushInterval(time.Hour))
+
+	err := cli.TrackJob(sender, "nightly-report", func() error {
+		clock.Advance(2 * time.Second)
+		return errors.New("no data")
+	})
+	sender.Close()
+
+	if err == nil || err.Error() != "no data" {
+		t.Errorf("Expected the job's error, got %v", err)
+	}
+	events := collector.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.ResponseUS != 2000000 {
+		t.Errorf("Expected ResponseUS 2000000 from the fake clock, got %d", event.ResponseUS)
+	}
+	if event.Timestamp != 1002 {
+		t.Errorf("Expected Timestamp 1002 from the fake clock, got %d", event.Timestamp)
+	}
+	if event.StatusCode != 500 || event.ErrorMessage != "no data" {
+		t.Errorf("Expected a failure with ErrorMessage \"no data\", got %d %q", event.StatusCode, event.ErrorMessage)
+	}
+	if _, ok := event.Data["error"]; ok {
+		t.Errorf("Error duplicated in Data: %v", event.Data)
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Package kafka publishes batches of apinalytics events to a Kafka topic, so they can flow through your existing streaming
+infrastructure on their way to apinalytics.
+
+	transport := kafka.NewTransport([]string{"kafka-1:9092", "kafka-2:9092"}, "apinalytics-events")
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "", apinalytics_client.WithTransport(transport))
+
+Each batch is split by ConsumerId, and the events for each consumer published as one message keyed by the
+ConsumerId, so all of a consumer's events land on the same partition in order.  Events without a ConsumerId share a
+partition too.  Messages are encoded as JSON arrays, or with the Codec set WithCodec, and carry content-type,
+batch-id and sdk headers.
+
+Failures to publish are retried according to the Sender's RetryPolicy, and spooled if it has a spool.
+*/
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+/*
+Transport is an apinalytics_client.Transport that publishes batches to a Kafka topic.  Create one with NewTransport.
+*/
+type Transport struct {
+	writer *kafkago.Writer
+	codec  cli.Codec
+}
+
+// Option configures optional behaviour of a Transport.
+type Option func(*Transport)
+
+/*
+WithCodec encodes the messages published with codec rather than apinalytics_client.JSONCodec.
+*/
+func WithCodec(codec cli.Codec) Option {
+	return func(transport *Transport) {
+		if codec != nil {
+			transport.codec = codec
+		}
+	}
+}
+
+/*
+WithBalancer chooses the partition for each message with balancer rather than by hashing the ConsumerId.
+*/
+func WithBalancer(balancer kafkago.Balancer) Option {
+	return func(transport *Transport) {
+		transport.writer.Balancer = balancer
+	}
+}
+
+/*
+WithKafkaTransport makes the transport connect to Kafka with roundTripper, e.g. a *kafkago.Transport with TLS or SASL
+configured.
+*/
+func WithKafkaTransport(roundTripper kafkago.RoundTripper) Option {
+	return func(transport *Transport) {
+		transport.writer.Transport = roundTripper
+	}
+}
+
+/*
+WithRequiredAcks sets how many replicas must acknowledge each message before it counts as published.  The default is
+kafkago.RequireAll.
+*/
+func WithRequiredAcks(acks kafkago.RequiredAcks) Option {
+	return func(transport *Transport) {
+		transport.writer.RequiredAcks = acks
+	}
+}
+
+/*
+NewTransport creates a Transport publishing to topic on the Kafka cluster with the given brokers.  Brokers aren't
+connected to until the first batch is sent.
+*/
+func NewTransport(brokers []string, topic string, options ...Option) *Transport {
+	transport := &Transport{
+		writer: &kafkago.Writer{
+			Addr:         kafkago.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafkago.Hash{},
+			RequiredAcks: kafkago.RequireAll,
+			// The Sender has already batched the events, so there's no point waiting for more
+			BatchTimeout: 10 * time.Millisecond,
+			// The Sender does the retrying
+			MaxAttempts: 1,
+		},
+		codec: cli.JSONCodec{},
+	}
+	for _, option := range options {
+		option(transport)
+	}
+	return transport
+}
+
+/*
+Send publishes the events in payload, one message per ConsumerId.  If only some of the messages are published the
+events in the others are rejected, as retryable unless the message was too large for the broker, so only they are sent
+again.  If none are published because a message was too large the Sender splits the batch and tries again.
+*/
+func (transport *Transport) Send(ctx context.Context, payload *cli.Payload) ([]cli.RejectedEvent, error) {
+	// Group the events by consumer, remembering where each came from in the batch
+	var keys []string
+	groups := make(map[string][]int)
+	for i, event := range payload.Batch.Events {
+		if _, ok := groups[event.ConsumerId]; !ok {
+			keys = append(keys, event.ConsumerId)
+		}
+		groups[event.ConsumerId] = append(groups[event.ConsumerId], i)
+	}
+
+	messages := make([]kafkago.Message, 0, len(keys))
+	for _, key := range keys {
+		events := make([]*cli.AnalyticsEvent, 0, len(groups[key]))
+		for _, i := range groups[key] {
+			events = append(events, payload.Batch.Events[i])
+		}
+		value, contentType, err := transport.codec.Marshal(events)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, kafkago.Message{
+			Key:   []byte(key),
+			Value: value,
+			Headers: []kafkago.Header{
+				{Key: "content-type", Value: []byte(contentType)},
+				{Key: "batch-id", Value: []byte(payload.Batch.ID)},
+				{Key: "sdk", Value: []byte("go/" + cli.SDKVersion)},
+			},
+		})
+	}
+
+	err := transport.writer.WriteMessages(ctx, messages...)
+	var writeErrors kafkago.WriteErrors
+	if errors.As(err, &writeErrors) && writeErrors.Count() < len(messages) {
+		// Some messages were published.  Ask for the rest to be sent again
+		var rejected []cli.RejectedEvent
+		for m, writeErr := range writeErrors {
+			if writeErr == nil {
+				continue
+			}
+			// Resending a message that's too large won't help, and splitting the batch would publish the rest again
+			retryable := !errors.Is(classify(writeErr), cli.ErrPayloadTooLarge)
+			for _, i := range groups[keys[m]] {
+				rejected = append(rejected, cli.RejectedEvent{Index: i, Error: writeErr.Error(), Retryable: retryable})
+			}
+		}
+		return rejected, nil
+	}
+	return nil, classify(err)
+}
+
+// Turn an error from publishing into one the Sender knows how to handle
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var tooLarge kafkago.MessageTooLargeError
+	if errors.As(err, &tooLarge) || errors.Is(err, kafkago.MessageSizeTooLarge) {
+		// The Sender splits the batch and tries again
+		return cli.ErrPayloadTooLarge
+	}
+	// WriteErrors doesn't unwrap to the errors for each message, so look through them
+	var writeErrors kafkago.WriteErrors
+	if errors.As(err, &writeErrors) {
+		for _, writeErr := range writeErrors {
+			if writeErr != nil && errors.Is(classify(writeErr), cli.ErrPayloadTooLarge) {
+				return cli.ErrPayloadTooLarge
+			}
+		}
+	}
+	// Most failures, e.g. brokers that can't be reached or a leader election, pass with time
+	return cli.Temporary(err)
+}
+
+/*
+Close flushes and closes the transport's connections to Kafka.  The Sender closes its Transport when it is closed.
+*/
+func (transport *Transport) Close() error {
+	return transport.writer.Close()
+}
+
+var _ cli.Transport = (*Transport)(nil)
@@ -0,0 +1,55 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+EventKind says what an event records.  The AnalyticsEvent fields are HTTP-shaped, but other kinds use the ones that
+make sense for them, and Validate only requires Method and StatusCode of HTTP events.
+*/
+type EventKind string
+
+const (
+	// An API call.  Events with no Kind are HTTP events
+	KindHTTP EventKind = "http"
+	// A background job run, with Function set to its name.  See JobEvent
+	KindJob EventKind = "job"
+	// A measurement, such as a queue depth or cache hit rate, with Function set to its name and Data["value"] to its
+	// value, or several related values in Data.  See NewMetricEvent and WithRuntimeMetrics
+	KindMetric EventKind = "metric"
+	// A liveness signal from a Sender, with Function "heartbeat".  See WithHeartbeat
+	KindHeartbeat EventKind = "heartbeat"
+	// Anything else, such as a business event, with Function set to its name and details in Data.  See
+	// NewCustomEvent
+	KindCustom EventKind = "custom"
+)
+
+/*
+IsHTTP reports whether event records an API call, i.e. has Kind KindHTTP or no Kind.
+*/
+func (event *AnalyticsEvent) IsHTTP() bool {
+	return event.Kind == "" || event.Kind == KindHTTP
+}
+
+/*
+NewCustomEvent creates an event of KindCustom called name, timestamped now, with data as its Data, e.g.
+
+	sender.Queue(apinalytics_client.NewCustomEvent("signup", map[string]interface{}{"plan": "pro"}))
+*/
+func NewCustomEvent(name string, data map[string]interface{}) *AnalyticsEvent {
+	event := &AnalyticsEvent{Kind: KindCustom, Function: name, Data: data}
+	event.SetTime(time.Now())
+	return event
+}
+
+/*
+NewMetricEvent creates an event of KindMetric recording that the measurement called name, e.g. "queue_depth", had
+value now.
+*/
+func NewMetricEvent(name string, value float64) *AnalyticsEvent {
+	event := &AnalyticsEvent{Kind: KindMetric, Function: name}
+	event.SetTime(time.Now())
+	event.SetFloat("value", value)
+	return event
+}
@@ -0,0 +1,139 @@
+/*
+Package lambda contains a wrapper for AWS Lambda API Gateway proxy handlers that reports invocations to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package lambda
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler is the signature of an API Gateway proxy handler.
+type Handler func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Implemented by Senders, so we can send events before Lambda freezes the function
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+/*
+Wrap wraps an API Gateway proxy handler so that each invocation is reported to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	lambda.Start(Wrap(handler, sender, nil))
+
+Lambda freezes the function, background goroutines included, as soon as the handler returns, so the wrapper flushes
+the sender before returning.  This adds the time taken to post the event to each invocation.  The flush gives up when
+ctx expires.  sender should have a Flush method as Sender does; if it hasn't Wrap logs a warning, as events may sit
+in the queue until the function is thawed, or be lost.
+
+The wrapper sets the same event fields as the middleware in apinalytics_client, from a request with the invocation's
+method, path, query string, headers and source IP.  Function is the API Gateway resource that matched, e.g.
+"/users/{id}".  If the handler returns an error StatusCode is 500, as API Gateway will respond with an error.
+
+To add your own data to the events reported add a callback, e.g. to record the API consumer.
+
+	callback := func(req events.APIGatewayProxyRequest, event *apinalytics_client.AnalyticsEvent) {
+		event.ConsumerId = req.RequestContext.Identity.APIKeyID
+	}
+
+Options from the apinalytics_client package, e.g. WithRequestSampleRate, WithExcludedPaths or WithClock, can be added
+after the callback.  WithRequestCallback callbacks are called before callback.
+*/
+func Wrap(h Handler, sender cli.Queuer,
+	callback func(req events.APIGatewayProxyRequest, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) Handler {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	f, canFlush := sender.(flusher)
+	if !canFlush {
+		log.Printf("Analytics sender %T can't be flushed, so events may be lost when Lambda freezes the function\n", sender)
+	}
+
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if !config.Paths.Allow(req.Path) {
+			return h(ctx, req)
+		}
+		start := config.Now()
+
+		rsp, err := h(ctx, req)
+
+		r := request(ctx, req)
+		ww := &cli.StatusTrackingResponseWriter{Status: rsp.StatusCode, Bytes: int64(len(rsp.Body))}
+		if err != nil {
+			ww.Status = 500
+		}
+		event := config.NewEvent(r, start, ww)
+		event.Function = req.Resource
+		if err != nil {
+			cli.SetError(event, err)
+		}
+		if config.Callback != nil {
+			config.Callback(event, r)
+		}
+		if callback != nil {
+			callback(req, event)
+		}
+		config.Report(event)
+
+		if canFlush {
+			if flushErr := f.Flush(ctx); flushErr != nil {
+				log.Printf("Failed to flush analytics events.  %v\n", flushErr)
+			}
+		}
+		return rsp, err
+	}
+}
+
+// Make up an *http.Request describing the invocation, so the middleware settings in apinalytics_client can be
+// applied to it
+func request(ctx context.Context, req events.APIGatewayProxyRequest) *http.Request {
+	header := http.Header{}
+	for key, values := range req.MultiValueHeaders {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	if len(req.MultiValueHeaders) == 0 {
+		for key, value := range req.Headers {
+			header.Set(key, value)
+		}
+	}
+	u, err := url.ParseRequestURI(requestURL(req))
+	if err != nil {
+		u = &url.URL{Path: req.Path}
+	}
+	r := &http.Request{
+		Method:     req.HTTPMethod,
+		URL:        u,
+		RequestURI: u.RequestURI(),
+		Header:     header,
+		Host:       header.Get("Host"),
+		RemoteAddr: req.RequestContext.Identity.SourceIP,
+	}
+	return r.WithContext(ctx)
+}
+
+// Rebuild the URL requested, with its query string
+func requestURL(req events.APIGatewayProxyRequest) string {
+	if len(req.MultiValueQueryStringParameters) == 0 && len(req.QueryStringParameters) == 0 {
+		return req.Path
+	}
+	query := url.Values(req.MultiValueQueryStringParameters)
+	if len(query) == 0 {
+		query = url.Values{}
+		for key, value := range req.QueryStringParameters {
+			query.Set(key, value)
+		}
+	}
+	return req.Path + "?" + query.Encode()
+}
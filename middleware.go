@@ -0,0 +1,249 @@
+package apinalytics_client
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+Wrap wraps h with middleware that reports each request it handles to sender, using only the standard library, so it
+works with http.ServeMux and most other routers.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	http.ListenAndServe(":8080", apinalytics_client.Wrap(mux, sender))
+
+The middleware sets the following event fields: Timestamp, Method, Url, Path, Query, UserAgent, Function,
+ResponseUS, StatusCode, RequestBytes, ResponseBytes, and TraceId and SpanId if the request has a traceparent header.
+Handlers can record why a request failed with RecordError, which sets ErrorMessage and ErrorType.  Function is
+"unknown" unless set by a callback added with WithRequestCallback, which can also set ConsumerId and Data.
+*/
+func Wrap(h http.Handler, sender Queuer, options ...MiddlewareOption) http.Handler {
+	config := MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+	return NewMiddleware(config)(h)
+}
+
+/*
+MiddlewareConfig holds everything the middleware needs to know.  Either set Sender, to share a Sender you've created,
+or ApplicationId, WriteKey, URL and optionally SenderOptions to have the middleware create its own.  The other fields
+are optional, and can be set directly or with MiddlewareOptions through Apply.
+
+	config := apinalytics_client.MiddlewareConfig{Sender: sender, Query: apinalytics_client.QueryDrop}
+	config.Apply(apinalytics_client.WithErrorsOnly())
+	handler := apinalytics_client.NewMiddleware(config)(mux)
+
+Integrations outside this package can build on the same struct with NewEvent, SetHeaders, Finish and Report, so the
+settings mean the same thing everywhere.
+*/
+type MiddlewareConfig struct {
+	// Where events are reported.  If nil, NewMiddleware creates a Sender from the following fields
+	Sender Queuer
+	// Credentials and URL for the Sender created if Sender is nil.  See NewSender
+	ApplicationId string
+	WriteKey      string
+	URL           string
+	// Options for the Sender created if Sender is nil
+	SenderOptions []Option
+	// Given each event before it is queued, along with the request.  See WithRequestCallback
+	Callback func(event *AnalyticsEvent, r *http.Request)
+	// Fraction of requests reported.  See WithRequestSampleRate
+	SampleRate float64
+	// Timestamps events and times requests.  Nil to use the time package
+	Clock Clock
+	// Which paths are reported.  See WithExcludedPaths and WithIncludedPaths
+	Paths PathRules
+	// Which responses are reported, by status.  See WithErrorsOnly and WithSuccessSampleRate
+	Status StatusSampling
+	// Request headers copied into Data.  See CaptureHeaders
+	Headers []string
+	// What's done with query strings.  See WithQueryMode
+	Query QueryMode
+	// Sets ClientIP.  Nil to leave it unset
+	ClientIP *ClientIPResolver
+	// Classifies the User-Agent into Data.  Nil to only set UserAgent.  See WithUserAgentParser
+	UserAgent UserAgentParser
+	// Headers RequestId is taken from.  See WithRequestID
+	RequestID []string
+	// Set ConsumerId.  See WithConsumerID
+	Consumer []ConsumerExtractor
+	// Static labels added to Data for matching routes.  See WithRouteTags
+	Tags []RouteTags
+	// Apdex threshold requests are rated against.  Zero to not rate them.  See WithApdex
+	Apdex time.Duration
+}
+
+// MiddlewareOption configures optional behaviour of the middleware built by Wrap or NewMiddleware.
+type MiddlewareOption func(*MiddlewareConfig)
+
+/*
+Apply applies options to config, in order.
+*/
+func (config *MiddlewareConfig) Apply(options ...MiddlewareOption) {
+	for _, option := range options {
+		option(config)
+	}
+}
+
+/*
+NewMiddleware builds middleware from config that reports each request it handles.  See Wrap for what is reported.
+*/
+func NewMiddleware(config MiddlewareConfig) func(http.Handler) http.Handler {
+	if config.Sender == nil {
+		config.Sender = NewSender(config.ApplicationId, config.WriteKey, config.URL, config.SenderOptions...)
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !config.Paths.Allow(r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			start := config.Now()
+			tw, ww := TrackResponse(w)
+			r = RecordErrors(r)
+
+			h.ServeHTTP(tw, r)
+
+			event := config.NewEvent(r, start, ww)
+			if config.Callback != nil {
+				config.Callback(event, r)
+			}
+			config.Report(event)
+		})
+	}
+}
+
+/*
+Now returns the time according to config's Clock.
+*/
+func (config *MiddlewareConfig) Now() time.Time {
+	if config.Clock != nil {
+		return config.Clock.Now()
+	}
+	return time.Now()
+}
+
+/*
+NewEvent creates the event for request r, which started at start and whose response was tracked by ww, setting the
+fields listed under Wrap as config says.  It is for middleware outside this package, which should pass r through
+RecordErrors before handling it, then set anything else it knows, such as Function, call its callback and pass the
+event to Report.
+*/
+func (config *MiddlewareConfig) NewEvent(r *http.Request, start time.Time, ww *StatusTrackingResponseWriter) *AnalyticsEvent {
+	event := &AnalyticsEvent{
+		Method:     r.Method,
+		Function:   "unknown",
+		StatusCode: ww.Status,
+	}
+	if r.ContentLength > 0 {
+		event.RequestBytes = r.ContentLength
+	}
+	event.ResponseBytes = ww.Bytes
+	SetRequestURL(event, r, config.Query)
+	if config.ClientIP != nil {
+		event.ClientIP = config.ClientIP.ClientIP(r)
+	}
+	config.SetHeaders(event, r.Header)
+	event.ConsumerId = ConsumerID(r, config.Consumer)
+	SetError(event, RecordedError(r.Context()))
+	config.Finish(event, start)
+	return event
+}
+
+/*
+SetHeaders sets the fields of event that config takes from request headers: UserAgent, classified by config's
+UserAgent parser, RequestId, TraceId and SpanId, and the Headers config captures.  It is for integrations outside this
+package that don't have an *http.Request but do have headers, e.g. gRPC metadata.
+*/
+func (config *MiddlewareConfig) SetHeaders(event *AnalyticsEvent, header http.Header) {
+	SetUserAgent(event, header.Get("User-Agent"), config.UserAgent)
+	event.RequestId = RequestID(header, config.RequestID)
+	SetTraceContext(event, header)
+	CopyHeaders(event, header, config.Headers)
+}
+
+/*
+Finish sets the fields of event that config sets once a call that started at start is over: Timestamp, Time,
+ResponseUS, SampleRate, Data["apdex"] if config has an Apdex threshold, and the Tags of routes matching event's Path.
+Set StatusCode and Path first.  NewEvent calls it; integrations outside this package that don't handle
+*http.Requests, such as database drivers and RPC interceptors, can call it directly before passing the event to Report.
+*/
+func (config *MiddlewareConfig) Finish(event *AnalyticsEvent, start time.Time) {
+	now := config.Now()
+	event.Timestamp = now.Unix()
+	event.Time = now
+	event.ResponseUS = int(now.Sub(start).Nanoseconds() / 1000)
+	event.SampleRate = config.SampleRate
+	if config.Apdex > 0 {
+		event.set("apdex", ApdexRating(event.ResponseUS, event.StatusCode, config.Apdex))
+	}
+	AddRouteTags(event, event.Path, config.Tags)
+}
+
+/*
+Report queues event to config's Sender, unless config's Status rules say it shouldn't be reported.
+*/
+func (config *MiddlewareConfig) Report(event *AnalyticsEvent) {
+	if config.Status.Apply(event) {
+		config.Sender.Queue(event)
+	}
+}
+
+/*
+WithRequestCallback adds a callback that is given each event before it is queued, along with the request, so you can
+add your own data, e.g. set ConsumerId from your authentication middleware or Function from your router.
+*/
+func WithRequestCallback(callback func(event *AnalyticsEvent, r *http.Request)) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Callback = callback
+	}
+}
+
+/*
+WithRequestSampleRate reports only a fraction of the requests handled by the middleware, overriding any sample rate
+set on the Sender.  See WithSampleRate.
+*/
+func WithRequestSampleRate(rate float64) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.SampleRate = rate
+	}
+}
+
+/*
+WithRequestClock makes the middleware use clock rather than the time package to timestamp events and time requests.
+*/
+func WithRequestClock(clock Clock) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Clock = clock
+	}
+}
+
+/*
+CaptureHeaders makes the middleware copy the named request headers into each event's Data, keyed by "header." and the
+canonical header name, e.g. Data["header.User-Agent"].  Only the headers listed are copied, so sensitive headers such
+as Authorization and Cookie are never captured by accident.  Repeated headers are joined with ", ".
+*/
+func CaptureHeaders(names ...string) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Headers = append(c.Headers, names...)
+	}
+}
+
+/*
+CopyHeaders copies the named headers from header into event's Data, as described under CaptureHeaders.  It is for
+middleware outside this package.
+*/
+func CopyHeaders(event *AnalyticsEvent, header http.Header, names []string) {
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if event.Data == nil {
+			event.Data = make(map[string]interface{}, len(names))
+		}
+		event.Data["header."+name] = strings.Join(values, ", ")
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Package msgpack encodes batches of apinalytics events as MessagePack (https://msgpack.org), which is quicker to
+encode and smaller than JSON.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/",
+		apinalytics_client.WithCodec(msgpack.Codec{}))
+
+Events are encoded as maps with the same keys as in JSON.  Batches are sent with Content-Type application/msgpack,
+and if apinalytics doesn't accept that the Sender falls back to JSON.
+*/
+package msgpack
+
+import (
+	"bytes"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	vmsgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType is the Content-Type batches are sent with.
+const ContentType = "application/msgpack"
+
+/*
+Codec is an apinalytics_client.BatchCodec that encodes batches as MessagePack.
+*/
+type Codec struct{}
+
+// Marshal encodes events as a MessagePack array.
+func (Codec) Marshal(events []*cli.AnalyticsEvent) ([]byte, string, error) {
+	data, err := marshal(events)
+	return data, ContentType, err
+}
+
+// MarshalBatch encodes batch as a MessagePack map.
+func (Codec) MarshalBatch(batch *cli.Batch) ([]byte, string, error) {
+	data, err := marshal(batch)
+	return data, ContentType, err
+}
+
+// Encode v using its JSON field names and omitempty rules
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := vmsgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var _ cli.BatchCodec = Codec{}
@@ -0,0 +1,84 @@
+/*
+Package negroni contains negroni (https://github.com/urfave/negroni) middleware for reporting events to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package negroni
+
+import (
+	"net/http"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	gonegroni "github.com/urfave/negroni"
+)
+
+/*
+Middleware is a negroni.Handler that reports HTTP requests to Apinalytics.  Create one with New.
+*/
+type Middleware struct {
+	callback func(event *cli.AnalyticsEvent, r *http.Request)
+	config   cli.MiddlewareConfig
+}
+
+/*
+New creates negroni middleware that reports HTTP requests to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	n := negroni.Classic()
+	n.Use(New(sender, nil))
+
+The middleware sets the same event fields as apinalytics_client.Wrap.  negroni doesn't route requests, so Function is
+"unknown" unless you set it in a callback.
+
+	callback := func(event *apinalytics_client.AnalyticsEvent, r *http.Request) {
+		event.Function = functionFor(r)
+		event.ConsumerId = r.Header.Get("X-Api-User")
+	}
+
+	n.Use(New(sender, callback))
+
+Options from the apinalytics_client package, e.g. WithRequestSampleRate or WithExcludedPaths, can be added after the
+callback.  A callback added with WithRequestCallback is called before callback.
+*/
+func New(sender cli.Queuer,
+	callback func(event *cli.AnalyticsEvent, r *http.Request),
+	options ...cli.MiddlewareOption,
+) *Middleware {
+	m := &Middleware{callback: callback, config: cli.MiddlewareConfig{Sender: sender}}
+	m.config.Apply(options...)
+	return m
+}
+
+/*
+ServeHTTP implements negroni.Handler.
+*/
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !m.config.Paths.Allow(r.URL.Path) {
+		next(rw, r)
+		return
+	}
+	start := m.config.Now()
+	// negroni passes a ResponseWriter that tracks the status and size for us, unless we're used outside negroni
+	nrw, ok := rw.(gonegroni.ResponseWriter)
+	if !ok {
+		nrw = gonegroni.NewResponseWriter(rw)
+	}
+	r = cli.RecordErrors(r)
+
+	next(nrw, r)
+
+	ww := &cli.StatusTrackingResponseWriter{Status: nrw.Status(), Bytes: int64(nrw.Size())}
+	if ww.Status == 0 {
+		ww.Status = http.StatusOK
+	}
+	event := m.config.NewEvent(r, start, ww)
+	if m.config.Callback != nil {
+		m.config.Callback(event, r)
+	}
+	if m.callback != nil {
+		m.callback(event, r)
+	}
+	m.config.Report(event)
+}
+
+var _ gonegroni.Handler = (*Middleware)(nil)
@@ -0,0 +1,106 @@
+package apinalytics_client
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+NormalizeRule rewrites a URL path, e.g. replacing IDs with placeholders.  Build rules with SegmentRule and PathRule,
+or write your own.
+*/
+type NormalizeRule func(path string) string
+
+/*
+SegmentRule returns a rule that replaces every path segment wholly matching pattern with placeholder, e.g.
+
+	SegmentRule(`\d+`, ":id")
+
+rewrites /users/123/orders/456 as /users/:id/orders/:id.
+*/
+func SegmentRule(pattern, placeholder string) NormalizeRule {
+	re := regexp.MustCompile(`^(?:` + pattern + `)$`)
+	return func(path string) string {
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if segment != "" && re.MatchString(segment) {
+				segments[i] = placeholder
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+}
+
+/*
+PathRule returns a rule that replaces matches of pattern anywhere in the path with replacement, which may refer to
+submatches as in regexp.Regexp.ReplaceAllString.
+*/
+func PathRule(pattern, replacement string) NormalizeRule {
+	re := regexp.MustCompile(pattern)
+	return func(path string) string {
+		return re.ReplaceAllString(path, replacement)
+	}
+}
+
+/*
+URLNormalizer rewrites the paths of events' URLs so that requests for the same endpoint look the same, e.g. /users/123
+becomes /users/:id.  This keeps per-endpoint aggregation meaningful when Function isn't set.  Create one with
+NewURLNormalizer or DefaultURLNormalizer and add it to a Sender with WithURLNormalizer.
+*/
+type URLNormalizer struct {
+	rules []NormalizeRule
+}
+
+/*
+NewURLNormalizer creates a URLNormalizer that applies rules in order.
+*/
+func NewURLNormalizer(rules ...NormalizeRule) *URLNormalizer {
+	return &URLNormalizer{rules: rules}
+}
+
+/*
+DefaultURLNormalizer creates a URLNormalizer that replaces path segments that are UUIDs with :uuid, hex strings of 16
+or more digits (hashes) with :hash, and numbers with :id.
+*/
+func DefaultURLNormalizer() *URLNormalizer {
+	return NewURLNormalizer(
+		SegmentRule(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, ":uuid"),
+		SegmentRule(`[0-9a-fA-F]{16,}`, ":hash"),
+		SegmentRule(`\d+`, ":id"),
+	)
+}
+
+/*
+WithURLNormalizer makes the sender normalize the path in every event's Url and Path with normalizer.  Normalizing
+happens in the background goroutine after any enrichers have run and before scrubbing.
+*/
+func WithURLNormalizer(normalizer *URLNormalizer) Option {
+	return func(sender *Sender) {
+		sender.normalizer = normalizer
+	}
+}
+
+/*
+Normalize rewrites the path in event's Url and Path in place.  Any query string in Url is left alone.  A nil
+URLNormalizer does nothing.
+*/
+func (normalizer *URLNormalizer) Normalize(event *AnalyticsEvent) {
+	if normalizer == nil || event == nil {
+		return
+	}
+	path, query := event.Url, ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i:]
+	}
+	event.Url = normalizer.normalize(path) + query
+	if event.Path != "" {
+		event.Path = normalizer.normalize(event.Path)
+	}
+}
+
+func (normalizer *URLNormalizer) normalize(path string) string {
+	for _, rule := range normalizer.rules {
+		path = rule(path)
+	}
+	return path
+}
@@ -0,0 +1,72 @@
+package apinalytics_client
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+Option configures optional behaviour of a Sender.  Pass options to NewSender.
+*/
+type Option func(*Sender)
+
+/*
+WithBatchSize sets the maximum number of events the background goroutine will send in a single POST.  The
+default is 90.
+*/
+func WithBatchSize(size int) Option {
+	return func(sender *Sender) {
+		if size > 0 {
+			sender.batchSize = size
+		}
+	}
+}
+
+/*
+WithQueueSize sets the number of events that can be queued to the background goroutine before Queue blocks.
+The default is 100.
+*/
+func WithQueueSize(size int) Option {
+	return func(sender *Sender) {
+		if size > 0 {
+			sender.queueSize = size
+		}
+	}
+}
+
+/*
+WithFlushInterval makes the background goroutine hold events until either a full batch is ready or the interval
+has passed, then send whatever is batched even if no new events have arrived.
+
+By default there is no interval and the background goroutine sends as soon as the queue is empty.
+*/
+func WithFlushInterval(interval time.Duration) Option {
+	return func(sender *Sender) {
+		sender.flushInterval = interval
+	}
+}
+
+/*
+WithHTTPClient makes the sender POST events using client rather than http.DefaultClient.  Use this to set timeouts,
+proxies, TLS settings or connection pooling limits.
+*/
+func WithHTTPClient(client *http.Client) Option {
+	return func(sender *Sender) {
+		if client != nil {
+			sender.client = client
+		}
+	}
+}
+
+/*
+WithMaxPayloadBytes limits the size of the body of each POST to apinalytics.  A batch that encodes to more than
+maxBytes is split in two and each half sent separately, repeatedly if need be.  Batches rejected by apinalytics with
+413 Request Entity Too Large are split in the same way, whether or not a limit is set.
+
+An event that is too large to send on its own is passed to the error handler with ErrPayloadTooLarge.
+*/
+func WithMaxPayloadBytes(maxBytes int) Option {
+	return func(sender *Sender) {
+		sender.maxPayload = maxBytes
+	}
+}
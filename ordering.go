@@ -0,0 +1,18 @@
+package apinalytics_client
+
+/*
+WithStrictOrdering makes the sender deliver batches strictly in the order their events were queued, for when
+whatever processes events downstream assumes they arrive in order.
+
+The sender already sends one batch at a time, retrying each until it is delivered or given up on and passed to the
+error handler and any dead letter hook.  What can get out of order are batches stored in a spool (see WithSpool),
+which are normally replayed after newer batches have been sent.  With strict ordering the spool is replayed before
+each new batch is sent, and while any spooled batch can't be sent new batches are spooled behind it rather than sent.
+Batches spooled only to keep them in order aren't counted in Stats().SendFailures, and nothing is replayed while the
+circuit breaker (see WithCircuitBreaker) is open.
+*/
+func WithStrictOrdering() Option {
+	return func(sender *Sender) {
+		sender.ordered = true
+	}
+}
@@ -0,0 +1,65 @@
+package apinalytics_client_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+func TestStrictOrderingWithCircuitOpen(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	spool, err := cli.NewFileSpool(filepath.Join(t.TempDir(), "spool"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithSpool(spool),
+		cli.WithStrictOrdering(), cli.WithCircuitBreaker(1, time.Minute), cli.WithErrorHandler(func(error, []*cli.AnalyticsEvent) {}))
+	defer sender.Close()
+	send := func(function string) {
+		sender.Queue(&cli.AnalyticsEvent{Method: "GET", Function: function, StatusCode: 200})
+		sender.Flush(context.Background())
+	}
+
+	// The first batch fails, is spooled and opens the circuit
+	collector.FailAll(503)
+	send("first")
+	if requests := collector.Requests(); requests != 1 {
+		t.Fatalf("Expected 1 request, got %d", requests)
+	}
+	if !sender.Stats().CircuitOpen {
+		t.Fatalf("Expected the circuit to be open")
+	}
+
+	// While the circuit is open the spool isn't replayed, and later batches wait behind it without counting as failures
+	send("second")
+	send("third")
+	if requests := collector.Requests(); requests != 1 {
+		t.Errorf("Expected no more requests while the circuit is open, got %d", requests-1)
+	}
+	if failures := sender.Stats().SendFailures; failures != 1 {
+		t.Errorf("Expected 1 send failure, got %d", failures)
+	}
+
+	// Once the cooldown has passed everything is sent, in order
+	collector.FailAll(0)
+	clock.Advance(2 * time.Minute)
+	send("fourth")
+	events := collector.Events()
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+	for i, function := range []string{"first", "second", "third", "fourth"} {
+		if events[i].Function != function {
+			t.Errorf("Expected event %d to be %s, got %s", i, function, events[i].Function)
+		}
+	}
+	if sender.Stats().CircuitOpen {
+		t.Errorf("Expected the circuit to be closed")
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Package otel connects apinalytics to OpenTelemetry (https://opentelemetry.io).  It stamps events with the trace and
+span IDs of the active span, so they can be joined with your traces, and can trace the Sender's own work.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/",
+		otel.WithTracing(tracerProvider.Tracer("apinalytics")))
+	handler := apinalytics_client.Wrap(otelhttp.NewHandler(mux, "api"), sender,
+		apinalytics_client.WithRequestCallback(otel.Callback(nil)))
+
+The active span is only in the request's context for middleware that runs inside the OpenTelemetry middleware.  If
+the apinalytics middleware runs outside it, as above, events still get trace IDs from the traceparent header.
+*/
+package otel
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+/*
+SetTraceContext sets event's TraceId and SpanId from the span active in ctx, if there is one.
+*/
+func SetTraceContext(event *cli.AnalyticsEvent, ctx context.Context) {
+	spanContext := oteltrace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	event.TraceId = spanContext.TraceID().String()
+	event.SpanId = spanContext.SpanID().String()
+}
+
+/*
+Callback returns a request callback, for use with apinalytics_client.WithRequestCallback, that sets each event's
+TraceId and SpanId from the span active in the request's context and then calls next, which may be nil.
+*/
+func Callback(next func(event *cli.AnalyticsEvent, r *http.Request)) func(event *cli.AnalyticsEvent, r *http.Request) {
+	return func(event *cli.AnalyticsEvent, r *http.Request) {
+		SetTraceContext(event, r.Context())
+		if next != nil {
+			next(event, r)
+		}
+	}
+}
+
+/*
+WithTracing makes the Sender record a span with tracer for each batch it posts, so you can see analytics delivery
+alongside the rest of your traces.  Spans are named "apinalytics.send" and have the batch ID and number of events as
+attributes.  Batches that can't be delivered are recorded as spans with an error status.
+
+WithTracing adds OnBatchSent and OnBatchFailed hooks to the Sender, which are called after any hooks set before it.
+*/
+func WithTracing(tracer oteltrace.Tracer) cli.Option {
+	return func(sender *cli.Sender) {
+		cli.OnBatchSent(func(batchID string, count int, took time.Duration) {
+			end := time.Now()
+			_, span := tracer.Start(context.Background(), "apinalytics.send",
+				oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+				oteltrace.WithTimestamp(end.Add(-took)),
+				oteltrace.WithAttributes(
+					attribute.String("apinalytics.batch_id", batchID),
+					attribute.Int("apinalytics.events", count),
+				),
+			)
+			span.End(oteltrace.WithTimestamp(end))
+		})(sender)
+
+		cli.OnBatchFailed(func(err error, count int) {
+			_, span := tracer.Start(context.Background(), "apinalytics.send",
+				oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+				oteltrace.WithAttributes(attribute.Int("apinalytics.events", count)),
+			)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+		})(sender)
+	}
+}
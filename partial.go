@@ -0,0 +1,89 @@
+package apinalytics_client
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+/*
+RejectedEvent describes an event in a batch that apinalytics accepted as a whole but rejected individually.
+*/
+type RejectedEvent struct {
+	// Position of the event in the batch, counting from 0
+	Index int `json:"index"`
+	// Why the event was rejected
+	Error string `json:"error"`
+	// True if the event may be accepted if sent again
+	Retryable bool `json:"retryable"`
+}
+
+/*
+ResponseParser interprets apinalytics' response to a POST that succeeded, reporting any events in the batch it
+rejected.  Rejected events marked retryable are sent again according to the sender's RetryPolicy.  Others, and those
+still rejected when retries are exhausted, are passed to the error handler and dead letter hook with ErrRejected.
+
+Rejected is called for every 2xx response.  The response body has not been read.
+
+The default ResponseParser is MultiStatusParser.  Set another with WithResponseParser if the server's response
+format changes.
+*/
+type ResponseParser interface {
+	Rejected(rsp *http.Response) ([]RejectedEvent, error)
+}
+
+/*
+MultiStatusParser is a ResponseParser for servers that report partial failure with a 207 Multi-Status response and a
+JSON body listing the rejected events.
+
+	{"rejected": [{"index": 3, "error": "timestamp out of range", "retryable": false}]}
+
+Any other 2xx response means every event was accepted.
+*/
+type MultiStatusParser struct{}
+
+// Rejected implements ResponseParser.
+func (MultiStatusParser) Rejected(rsp *http.Response) ([]RejectedEvent, error) {
+	if rsp.StatusCode != http.StatusMultiStatus {
+		return nil, nil
+	}
+	var body struct {
+		Rejected []RejectedEvent `json:"rejected"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Rejected, nil
+}
+
+/*
+WithResponseParser makes the sender use parser to find events apinalytics rejected from a batch.
+*/
+func WithResponseParser(parser ResponseParser) Option {
+	return func(sender *Sender) {
+		if parser != nil {
+			sender.parser = parser
+		}
+	}
+}
+
+// Split the events apinalytics rejected from a batch into those worth retrying, which are returned, and those that
+// aren't, which are failed.  Also returns how many events were rejected in total
+func (sender *Sender) sortRejected(events []*AnalyticsEvent, rejected []RejectedEvent) ([]*AnalyticsEvent, int) {
+	var retry, failed []*AnalyticsEvent
+	seen := make(map[int]bool, len(rejected))
+	for _, r := range rejected {
+		if r.Index < 0 || r.Index >= len(events) || seen[r.Index] {
+			continue
+		}
+		seen[r.Index] = true
+		if r.Retryable {
+			retry = append(retry, events[r.Index])
+		} else {
+			failed = append(failed, events[r.Index])
+		}
+	}
+	if len(failed) > 0 {
+		sender.fail(ErrRejected, failed)
+	}
+	return retry, len(seen)
+}
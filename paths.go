@@ -0,0 +1,63 @@
+package apinalytics_client
+
+import (
+	"strings"
+)
+
+/*
+PathRules decides which request paths middleware reports, so infrastructure endpoints such as health checks don't use
+up your event quota.  Each rule is either an exact path, e.g. "/healthz", or a prefix ending in *, e.g. "/debug/*".
+
+A path is reported if it matches no Exclude rule and, if there are any Include rules, matches at least one of them.
+The zero PathRules reports everything.
+*/
+type PathRules struct {
+	// Only report paths matching one of these, if there are any
+	Include []string
+	// Never report paths matching any of these
+	Exclude []string
+}
+
+/*
+Allow reports whether requests for path should be reported.
+*/
+func (rules PathRules) Allow(path string) bool {
+	if matchPath(rules.Exclude, path) {
+		return false
+	}
+	return len(rules.Include) == 0 || matchPath(rules.Include, path)
+}
+
+// Check path against a list of rules
+func matchPath(rules []string, path string) bool {
+	for _, rule := range rules {
+		if prefix := strings.TrimSuffix(rule, "*"); prefix != rule {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == rule {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+WithExcludedPaths stops the middleware reporting requests for the given paths, e.g. "/healthz", "/metrics" and
+"/favicon.ico".  Paths ending in * are prefixes.  See PathRules.
+*/
+func WithExcludedPaths(paths ...string) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Paths.Exclude = append(c.Paths.Exclude, paths...)
+	}
+}
+
+/*
+WithIncludedPaths makes the middleware report only requests for the given paths, e.g. "/api/*".  Paths ending in *
+are prefixes.  See PathRules.
+*/
+func WithIncludedPaths(paths ...string) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Paths.Include = append(c.Paths.Include, paths...)
+	}
+}
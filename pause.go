@@ -0,0 +1,45 @@
+package apinalytics_client
+
+import (
+	"sync/atomic"
+)
+
+/*
+Pause stops the sender posting events to apinalytics, e.g. during an incident at the collector, without restarting
+your process.  Events continue to be queued and are held by the background goroutine, up to the limit set by
+WithPauseBuffer.  Beyond that the oldest events are discarded and counted in Stats().Dropped.
+
+Closing a paused sender still sends the events it holds.
+*/
+func (sender *Sender) Pause() {
+	atomic.StoreInt32(&sender.paused, 1)
+}
+
+/*
+Resume restarts delivery after Pause, sending everything held while the sender was paused.
+*/
+func (sender *Sender) Resume() {
+	if atomic.CompareAndSwapInt32(&sender.paused, 1, 0) {
+		select {
+		case sender.resumed <- struct{}{}:
+		default:
+			// The background goroutine has already been told
+		}
+	}
+}
+
+/*
+WithPauseBuffer sets the maximum number of events held while the sender is paused.  The default is 10000.
+*/
+func WithPauseBuffer(size int) Option {
+	return func(sender *Sender) {
+		if size > 0 {
+			sender.pauseBuffer = size
+		}
+	}
+}
+
+// Report whether the sender is paused
+func (sender *Sender) isPaused() bool {
+	return atomic.LoadInt32(&sender.paused) == 1
+}
@@ -0,0 +1,235 @@
+/*
+Package platform detects where your service is running, e.g. the Kubernetes pod or the EC2 or GCE instance, and
+provides an enricher that stamps it on every event, so latency anomalies can be traced to a particular pod or host.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/",
+		apinalytics_client.WithEnrichers(platform.Enricher(context.Background())))
+
+Detection happens once, when Detect or Enricher is called, not for each event.
+*/
+package platform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+const (
+	// Where the Kubernetes service account namespace is mounted in pods
+	namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	// Instance metadata services
+	ec2MetadataURL = "http://169.254.169.254/latest"
+	gceMetadataURL = "http://metadata.google.internal/computeMetadata/v1"
+	// How long we wait for each metadata service.  They answer quickly or not at all
+	metadataTimeout = 500 * time.Millisecond
+	// Where Linux exposes the firmware's idea of the machine, which names the cloud provider on cloud instances
+	dmiDir = "/sys/class/dmi/id/"
+	// Where Xen guests, such as older EC2 instances, find their UUID, which starts "ec2" on EC2
+	hypervisorUUIDFile = "/sys/hypervisor/uuid"
+)
+
+// The client for metadata services.  They're link-local, so we never go through a proxy, even if the environment
+// sets one for everything else
+var metadataClient = &http.Client{
+	Transport: &http.Transport{Proxy: nil},
+	Timeout:   metadataTimeout,
+}
+
+/*
+Metadata describes where the service is running.  Fields are empty if they couldn't be detected.
+*/
+type Metadata struct {
+	// Kubernetes pod, namespace and node.  The pod and node come from the POD_NAME and NODE_NAME environment variables,
+	// which you need to set with the downward API, the namespace from POD_NAMESPACE or the service account
+	Pod       string
+	Namespace string
+	Node      string
+	// Cloud provider, "aws" or "gcp"
+	Provider string
+	// ID of the cloud instance
+	InstanceId string
+	// Availability zone of the cloud instance
+	Zone string
+}
+
+/*
+Detect works out where the service is running, from the environment and the instance metadata services.  A metadata
+service is only asked if the machine's firmware (as shown under /sys/class/dmi/id) or the environment says the service
+is running on that provider, so Detect returns straight away elsewhere, e.g. on a laptop.  Each metadata service is
+given up on when ctx is done, or after half a second.
+*/
+func Detect(ctx context.Context) Metadata {
+	var metadata Metadata
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		metadata.Pod = os.Getenv("POD_NAME")
+		if metadata.Pod == "" {
+			// Pods' host names are their names unless set otherwise
+			metadata.Pod, _ = os.Hostname()
+		}
+		metadata.Namespace = os.Getenv("POD_NAMESPACE")
+		if metadata.Namespace == "" {
+			if namespace, err := os.ReadFile(namespaceFile); err == nil {
+				metadata.Namespace = strings.TrimSpace(string(namespace))
+			}
+		}
+		metadata.Node = os.Getenv("NODE_NAME")
+	}
+
+	if onEC2() && probe(ctx, &metadata, detectEC2) {
+		return metadata
+	}
+	if onGCE() {
+		probe(ctx, &metadata, detectGCE)
+	}
+	return metadata
+}
+
+// Run detect with its own timeout, so a slow metadata service doesn't use up the time for the next
+func probe(ctx context.Context, metadata *Metadata, detect func(context.Context, *Metadata) bool) bool {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	return detect(ctx, metadata)
+}
+
+// Whether the firmware or environment says we're on AWS.  ECS and EKS containers see the host's firmware
+func onEC2() bool {
+	if os.Getenv("AWS_EXECUTION_ENV") != "" || os.Getenv("ECS_CONTAINER_METADATA_URI_V4") != "" {
+		return true
+	}
+	return strings.HasPrefix(readFile(dmiDir+"sys_vendor"), "Amazon") ||
+		strings.HasPrefix(readFile(dmiDir+"board_asset_tag"), "i-") ||
+		strings.HasPrefix(strings.ToLower(readFile(hypervisorUUIDFile)), "ec2")
+}
+
+// Whether the firmware or environment says we're on Google Cloud
+func onGCE() bool {
+	if os.Getenv("GCE_METADATA_HOST") != "" || os.Getenv("K_SERVICE") != "" {
+		return true
+	}
+	return strings.HasPrefix(readFile(dmiDir+"product_name"), "Google") ||
+		strings.HasPrefix(readFile(dmiDir+"bios_vendor"), "Google")
+}
+
+// The trimmed contents of a small file, or "" if it can't be read
+func readFile(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// Ask the EC2 instance metadata service about the instance, using IMDSv2
+func detectEC2(ctx context.Context, metadata *Metadata) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, ec2MetadataURL+"/api/token", nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, ok := fetch(req)
+	if !ok {
+		return false
+	}
+	get := func(path string) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ec2MetadataURL+"/meta-data/"+path, nil)
+		if err != nil {
+			return ""
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		value, _ := fetch(req)
+		return value
+	}
+	metadata.InstanceId = get("instance-id")
+	if metadata.InstanceId == "" {
+		return false
+	}
+	metadata.Provider = "aws"
+	metadata.Zone = get("placement/availability-zone")
+	return true
+}
+
+// Ask the GCE metadata server about the instance
+func detectGCE(ctx context.Context, metadata *Metadata) bool {
+	get := func(path string) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gceMetadataURL+"/instance/"+path, nil)
+		if err != nil {
+			return ""
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		value, _ := fetch(req)
+		return value
+	}
+	metadata.InstanceId = get("id")
+	if metadata.InstanceId == "" {
+		return false
+	}
+	metadata.Provider = "gcp"
+	// The zone comes as projects/<number>/zones/<zone>
+	zone := get("zone")
+	metadata.Zone = zone[strings.LastIndex(zone, "/")+1:]
+	return true
+}
+
+// Make a request to a metadata service, returning the body if it succeeded
+func fetch(req *http.Request) (string, bool) {
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(body)), true
+}
+
+/*
+Enricher returns an enricher that adds metadata to each event's Data, keyed "k8s.pod", "k8s.namespace", "k8s.node",
+"cloud.provider", "cloud.instance_id" and "cloud.zone".  Empty values are left out.
+*/
+func (metadata Metadata) Enricher() cli.Enricher {
+	values := map[string]string{}
+	for key, value := range map[string]string{
+		"k8s.pod":           metadata.Pod,
+		"k8s.namespace":     metadata.Namespace,
+		"k8s.node":          metadata.Node,
+		"cloud.provider":    metadata.Provider,
+		"cloud.instance_id": metadata.InstanceId,
+		"cloud.zone":        metadata.Zone,
+	} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+
+	return func(event *cli.AnalyticsEvent) {
+		if len(values) == 0 {
+			return
+		}
+		if event.Data == nil {
+			event.Data = make(map[string]interface{}, len(values))
+		}
+		for key, value := range values {
+			if _, ok := event.Data[key]; !ok {
+				event.Data[key] = value
+			}
+		}
+	}
+}
+
+/*
+Enricher detects where the service is running, as Detect, and returns an enricher that adds it to each event.
+*/
+func Enricher(ctx context.Context) cli.Enricher {
+	return Detect(ctx).Enricher()
+}
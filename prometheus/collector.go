@@ -0,0 +1,78 @@
+/*
+Package prometheus exposes the delivery statistics of an apinalytics Sender as Prometheus metrics, so you can alert
+when analytics delivery degrades.
+
+	import apinalyticsprom "github.com/apinalytics/apinalytics_client/prometheus"
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	prometheus.MustRegister(apinalyticsprom.NewCollector(sender, "myapp", nil))
+*/
+package prometheus
+
+import (
+	cli "github.com/apinalytics/apinalytics_client"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+Collector is a prometheus.Collector that reports a Sender's statistics each time it is scraped.  Create one with
+NewCollector and register it with your Prometheus registry.
+
+The metrics reported, prefixed with the namespace, are
+
+	apinalytics_queue_depth             - events waiting to be sent
+	apinalytics_events_sent_total       - events successfully posted
+	apinalytics_batches_sent_total      - batches successfully posted
+	apinalytics_send_failures_total     - batches that could not be sent
+	apinalytics_events_dropped_total    - events discarded because the queue was full
+	apinalytics_send_duration_seconds   - summary of the time taken to post batches
+*/
+type Collector struct {
+	sender       *cli.Sender
+	queueDepth   *prom.Desc
+	eventsSent   *prom.Desc
+	batchesSent  *prom.Desc
+	sendFailures *prom.Desc
+	dropped      *prom.Desc
+	sendDuration *prom.Desc
+}
+
+/*
+NewCollector creates a Collector for sender.  namespace prefixes the metric names and may be empty.  labels are
+added to every metric, which is useful to tell senders apart if you have more than one.
+*/
+func NewCollector(sender *cli.Sender, namespace string, labels prom.Labels) *Collector {
+	desc := func(name, help string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName(namespace, "apinalytics", name), help, nil, labels)
+	}
+	return &Collector{
+		sender:       sender,
+		queueDepth:   desc("queue_depth", "Events queued or batched and waiting to be sent to apinalytics."),
+		eventsSent:   desc("events_sent_total", "Events successfully posted to apinalytics."),
+		batchesSent:  desc("batches_sent_total", "Batches of events successfully posted to apinalytics."),
+		sendFailures: desc("send_failures_total", "Batches of events that could not be sent to apinalytics."),
+		dropped:      desc("events_dropped_total", "Events discarded because the queue to apinalytics was full."),
+		sendDuration: desc("send_duration_seconds", "Time taken to post batches of events to apinalytics."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.queueDepth
+	ch <- c.eventsSent
+	ch <- c.batchesSent
+	ch <- c.sendFailures
+	ch <- c.dropped
+	ch <- c.sendDuration
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	stats := c.sender.Stats()
+	ch <- prom.MustNewConstMetric(c.queueDepth, prom.GaugeValue, float64(stats.Queued))
+	ch <- prom.MustNewConstMetric(c.eventsSent, prom.CounterValue, float64(stats.EventsSent))
+	ch <- prom.MustNewConstMetric(c.batchesSent, prom.CounterValue, float64(stats.BatchesSent))
+	ch <- prom.MustNewConstMetric(c.sendFailures, prom.CounterValue, float64(stats.SendFailures))
+	ch <- prom.MustNewConstMetric(c.dropped, prom.CounterValue, float64(stats.Dropped))
+	ch <- prom.MustNewConstSummary(c.sendDuration, stats.BatchesSent, stats.SendTime.Seconds(), nil)
+}
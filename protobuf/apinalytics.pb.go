@@ -0,0 +1,639 @@
+// Wire format for batches of apinalytics events sent with the protobuf codec.  Field numbers must never be reused;
+// add new fields with new numbers so old and new clients and servers can talk to each other.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: apinalytics.proto
+
+package protobuf
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// An API call, or another kind of event.  See apinalytics_client.AnalyticsEvent
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	TimestampUnit string                 `protobuf:"bytes,2,opt,name=timestamp_unit,json=timestampUnit,proto3" json:"timestamp_unit,omitempty"`
+	ConsumerId    string                 `protobuf:"bytes,3,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	TraceId       string                 `protobuf:"bytes,5,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanId        string                 `protobuf:"bytes,6,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	ClientIp      string                 `protobuf:"bytes,7,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,8,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Method        string                 `protobuf:"bytes,9,opt,name=method,proto3" json:"method,omitempty"`
+	Url           string                 `protobuf:"bytes,10,opt,name=url,proto3" json:"url,omitempty"`
+	Path          string                 `protobuf:"bytes,11,opt,name=path,proto3" json:"path,omitempty"`
+	Query         string                 `protobuf:"bytes,12,opt,name=query,proto3" json:"query,omitempty"`
+	Function      string                 `protobuf:"bytes,13,opt,name=function,proto3" json:"function,omitempty"`
+	ResponseUs    int64                  `protobuf:"varint,14,opt,name=response_us,json=responseUs,proto3" json:"response_us,omitempty"`
+	StatusCode    int32                  `protobuf:"varint,15,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,16,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	ErrorType     string                 `protobuf:"bytes,17,opt,name=error_type,json=errorType,proto3" json:"error_type,omitempty"`
+	Data          *structpb.Struct       `protobuf:"bytes,18,opt,name=data,proto3" json:"data,omitempty"`
+	RequestBytes  int64                  `protobuf:"varint,19,opt,name=request_bytes,json=requestBytes,proto3" json:"request_bytes,omitempty"`
+	ResponseBytes int64                  `protobuf:"varint,20,opt,name=response_bytes,json=responseBytes,proto3" json:"response_bytes,omitempty"`
+	SampleRate    float64                `protobuf:"fixed64,21,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Hostname      string                 `protobuf:"bytes,22,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	ServiceName   string                 `protobuf:"bytes,23,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Environment   string                 `protobuf:"bytes,24,opt,name=environment,proto3" json:"environment,omitempty"`
+	Version       string                 `protobuf:"bytes,25,opt,name=version,proto3" json:"version,omitempty"`
+	Kind          string                 `protobuf:"bytes,26,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_apinalytics_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_apinalytics_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_apinalytics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Event) GetTimestampUnit() string {
+	if x != nil {
+		return x.TimestampUnit
+	}
+	return ""
+}
+
+func (x *Event) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *Event) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Event) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *Event) GetSpanId() string {
+	if x != nil {
+		return x.SpanId
+	}
+	return ""
+}
+
+func (x *Event) GetClientIp() string {
+	if x != nil {
+		return x.ClientIp
+	}
+	return ""
+}
+
+func (x *Event) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *Event) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Event) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Event) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Event) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *Event) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *Event) GetResponseUs() int64 {
+	if x != nil {
+		return x.ResponseUs
+	}
+	return 0
+}
+
+func (x *Event) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *Event) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *Event) GetErrorType() string {
+	if x != nil {
+		return x.ErrorType
+	}
+	return ""
+}
+
+func (x *Event) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Event) GetRequestBytes() int64 {
+	if x != nil {
+		return x.RequestBytes
+	}
+	return 0
+}
+
+func (x *Event) GetResponseBytes() int64 {
+	if x != nil {
+		return x.ResponseBytes
+	}
+	return 0
+}
+
+func (x *Event) GetSampleRate() float64 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *Event) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Event) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *Event) GetEnvironment() string {
+	if x != nil {
+		return x.Environment
+	}
+	return ""
+}
+
+func (x *Event) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Event) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+// The client library that sent a batch
+type SDK struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Runtime       string                 `protobuf:"bytes,3,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SDK) Reset() {
+	*x = SDK{}
+	mi := &file_apinalytics_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SDK) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SDK) ProtoMessage() {}
+
+func (x *SDK) ProtoReflect() protoreflect.Message {
+	mi := &file_apinalytics_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SDK.ProtoReflect.Descriptor instead.
+func (*SDK) Descriptor() ([]byte, []int) {
+	return file_apinalytics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SDK) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SDK) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *SDK) GetRuntime() string {
+	if x != nil {
+		return x.Runtime
+	}
+	return ""
+}
+
+// Response times of the events in a batch for one function
+type LatencySummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Function      string                 `protobuf:"bytes,1,opt,name=function,proto3" json:"function,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	P50Us         int64                  `protobuf:"varint,3,opt,name=p50_us,json=p50Us,proto3" json:"p50_us,omitempty"`
+	P95Us         int64                  `protobuf:"varint,4,opt,name=p95_us,json=p95Us,proto3" json:"p95_us,omitempty"`
+	P99Us         int64                  `protobuf:"varint,5,opt,name=p99_us,json=p99Us,proto3" json:"p99_us,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatencySummary) Reset() {
+	*x = LatencySummary{}
+	mi := &file_apinalytics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencySummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencySummary) ProtoMessage() {}
+
+func (x *LatencySummary) ProtoReflect() protoreflect.Message {
+	mi := &file_apinalytics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencySummary.ProtoReflect.Descriptor instead.
+func (*LatencySummary) Descriptor() ([]byte, []int) {
+	return file_apinalytics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LatencySummary) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *LatencySummary) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *LatencySummary) GetP50Us() int64 {
+	if x != nil {
+		return x.P50Us
+	}
+	return 0
+}
+
+func (x *LatencySummary) GetP95Us() int64 {
+	if x != nil {
+		return x.P95Us
+	}
+	return 0
+}
+
+func (x *LatencySummary) GetP99Us() int64 {
+	if x != nil {
+		return x.P99Us
+	}
+	return 0
+}
+
+// A batch sent without an envelope
+type Events struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Events) Reset() {
+	*x = Events{}
+	mi := &file_apinalytics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Events) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Events) ProtoMessage() {}
+
+func (x *Events) ProtoReflect() protoreflect.Message {
+	mi := &file_apinalytics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Events.ProtoReflect.Descriptor instead.
+func (*Events) Descriptor() ([]byte, []int) {
+	return file_apinalytics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Events) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// A batch sent in an envelope
+type Batch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Events        []*Event               `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	Sdk           *SDK                   `protobuf:"bytes,3,opt,name=sdk,proto3" json:"sdk,omitempty"`
+	Summaries     []*LatencySummary      `protobuf:"bytes,4,rep,name=summaries,proto3" json:"summaries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Batch) Reset() {
+	*x = Batch{}
+	mi := &file_apinalytics_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Batch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Batch) ProtoMessage() {}
+
+func (x *Batch) ProtoReflect() protoreflect.Message {
+	mi := &file_apinalytics_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Batch.ProtoReflect.Descriptor instead.
+func (*Batch) Descriptor() ([]byte, []int) {
+	return file_apinalytics_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Batch) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *Batch) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Batch) GetSdk() *SDK {
+	if x != nil {
+		return x.Sdk
+	}
+	return nil
+}
+
+func (x *Batch) GetSummaries() []*LatencySummary {
+	if x != nil {
+		return x.Summaries
+	}
+	return nil
+}
+
+var File_apinalytics_proto protoreflect.FileDescriptor
+
+const file_apinalytics_proto_rawDesc = "" +
+	"\n" +
+	"\x11apinalytics.proto\x12\x0eapinalytics.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x9b\x06\n" +
+	"\x05Event\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12%\n" +
+	"\x0etimestamp_unit\x18\x02 \x01(\tR\rtimestampUnit\x12\x1f\n" +
+	"\vconsumer_id\x18\x03 \x01(\tR\n" +
+	"consumerId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\x12\x19\n" +
+	"\btrace_id\x18\x05 \x01(\tR\atraceId\x12\x17\n" +
+	"\aspan_id\x18\x06 \x01(\tR\x06spanId\x12\x1b\n" +
+	"\tclient_ip\x18\a \x01(\tR\bclientIp\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\b \x01(\tR\tuserAgent\x12\x16\n" +
+	"\x06method\x18\t \x01(\tR\x06method\x12\x10\n" +
+	"\x03url\x18\n" +
+	" \x01(\tR\x03url\x12\x12\n" +
+	"\x04path\x18\v \x01(\tR\x04path\x12\x14\n" +
+	"\x05query\x18\f \x01(\tR\x05query\x12\x1a\n" +
+	"\bfunction\x18\r \x01(\tR\bfunction\x12\x1f\n" +
+	"\vresponse_us\x18\x0e \x01(\x03R\n" +
+	"responseUs\x12\x1f\n" +
+	"\vstatus_code\x18\x0f \x01(\x05R\n" +
+	"statusCode\x12#\n" +
+	"\rerror_message\x18\x10 \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"error_type\x18\x11 \x01(\tR\terrorType\x12+\n" +
+	"\x04data\x18\x12 \x01(\v2\x17.google.protobuf.StructR\x04data\x12#\n" +
+	"\rrequest_bytes\x18\x13 \x01(\x03R\frequestBytes\x12%\n" +
+	"\x0eresponse_bytes\x18\x14 \x01(\x03R\rresponseBytes\x12\x1f\n" +
+	"\vsample_rate\x18\x15 \x01(\x01R\n" +
+	"sampleRate\x12\x1a\n" +
+	"\bhostname\x18\x16 \x01(\tR\bhostname\x12!\n" +
+	"\fservice_name\x18\x17 \x01(\tR\vserviceName\x12 \n" +
+	"\venvironment\x18\x18 \x01(\tR\venvironment\x12\x18\n" +
+	"\aversion\x18\x19 \x01(\tR\aversion\x12\x12\n" +
+	"\x04kind\x18\x1a \x01(\tR\x04kind\"M\n" +
+	"\x03SDK\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x18\n" +
+	"\aruntime\x18\x03 \x01(\tR\aruntime\"\x87\x01\n" +
+	"\x0eLatencySummary\x12\x1a\n" +
+	"\bfunction\x18\x01 \x01(\tR\bfunction\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x15\n" +
+	"\x06p50_us\x18\x03 \x01(\x03R\x05p50Us\x12\x15\n" +
+	"\x06p95_us\x18\x04 \x01(\x03R\x05p95Us\x12\x15\n" +
+	"\x06p99_us\x18\x05 \x01(\x03R\x05p99Us\"7\n" +
+	"\x06Events\x12-\n" +
+	"\x06events\x18\x01 \x03(\v2\x15.apinalytics.v1.EventR\x06events\"\xb6\x01\n" +
+	"\x05Batch\x12\x19\n" +
+	"\bbatch_id\x18\x01 \x01(\tR\abatchId\x12-\n" +
+	"\x06events\x18\x02 \x03(\v2\x15.apinalytics.v1.EventR\x06events\x12%\n" +
+	"\x03sdk\x18\x03 \x01(\v2\x13.apinalytics.v1.SDKR\x03sdk\x12<\n" +
+	"\tsummaries\x18\x04 \x03(\v2\x1e.apinalytics.v1.LatencySummaryR\tsummariesB4Z2github.com/apinalytics/apinalytics_client/protobufb\x06proto3"
+
+var (
+	file_apinalytics_proto_rawDescOnce sync.Once
+	file_apinalytics_proto_rawDescData []byte
+)
+
+func file_apinalytics_proto_rawDescGZIP() []byte {
+	file_apinalytics_proto_rawDescOnce.Do(func() {
+		file_apinalytics_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_apinalytics_proto_rawDesc), len(file_apinalytics_proto_rawDesc)))
+	})
+	return file_apinalytics_proto_rawDescData
+}
+
+var file_apinalytics_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_apinalytics_proto_goTypes = []any{
+	(*Event)(nil),           // 0: apinalytics.v1.Event
+	(*SDK)(nil),             // 1: apinalytics.v1.SDK
+	(*LatencySummary)(nil),  // 2: apinalytics.v1.LatencySummary
+	(*Events)(nil),          // 3: apinalytics.v1.Events
+	(*Batch)(nil),           // 4: apinalytics.v1.Batch
+	(*structpb.Struct)(nil), // 5: google.protobuf.Struct
+}
+var file_apinalytics_proto_depIdxs = []int32{
+	5, // 0: apinalytics.v1.Event.data:type_name -> google.protobuf.Struct
+	0, // 1: apinalytics.v1.Events.events:type_name -> apinalytics.v1.Event
+	0, // 2: apinalytics.v1.Batch.events:type_name -> apinalytics.v1.Event
+	1, // 3: apinalytics.v1.Batch.sdk:type_name -> apinalytics.v1.SDK
+	2, // 4: apinalytics.v1.Batch.summaries:type_name -> apinalytics.v1.LatencySummary
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_apinalytics_proto_init() }
+func file_apinalytics_proto_init() {
+	if File_apinalytics_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apinalytics_proto_rawDesc), len(file_apinalytics_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_apinalytics_proto_goTypes,
+		DependencyIndexes: file_apinalytics_proto_depIdxs,
+		MessageInfos:      file_apinalytics_proto_msgTypes,
+	}.Build()
+	File_apinalytics_proto = out.File
+	file_apinalytics_proto_goTypes = nil
+	file_apinalytics_proto_depIdxs = nil
+}
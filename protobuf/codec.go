@@ -0,0 +1,132 @@
+/*
+Package protobuf encodes batches of apinalytics events as Protocol Buffers (https://protobuf.dev), using the schema in
+apinalytics.proto, for compact payloads whose fields can evolve safely.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/",
+		apinalytics_client.WithCodec(protobuf.Codec{}))
+
+Batches are sent as an Events message, or a Batch message if the Sender was created WithEnvelope, with Content-Type
+application/x-protobuf.  If apinalytics doesn't accept that the Sender falls back to JSON.
+
+Event Data is sent as a google.protobuf.Struct, so numbers in it arrive as doubles.
+*/
+package protobuf
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative apinalytics.proto
+
+import (
+	"encoding/json"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ContentType is the Content-Type batches are sent with.
+const ContentType = "application/x-protobuf"
+
+/*
+Codec is an apinalytics_client.BatchCodec that encodes batches as Protocol Buffers.
+*/
+type Codec struct{}
+
+// Marshal encodes events as an Events message.
+func (Codec) Marshal(events []*cli.AnalyticsEvent) ([]byte, string, error) {
+	msg := &Events{}
+	for _, event := range events {
+		pb, err := FromEvent(event)
+		if err != nil {
+			return nil, "", err
+		}
+		msg.Events = append(msg.Events, pb)
+	}
+	data, err := proto.Marshal(msg)
+	return data, ContentType, err
+}
+
+// MarshalBatch encodes batch as a Batch message.
+func (Codec) MarshalBatch(batch *cli.Batch) ([]byte, string, error) {
+	msg := &Batch{BatchId: batch.ID}
+	for _, event := range batch.Events {
+		pb, err := FromEvent(event)
+		if err != nil {
+			return nil, "", err
+		}
+		msg.Events = append(msg.Events, pb)
+	}
+	if batch.SDK != nil {
+		msg.Sdk = &SDK{Name: batch.SDK.Name, Version: batch.SDK.Version, Runtime: batch.SDK.Runtime}
+	}
+	for _, summary := range batch.Summaries {
+		msg.Summaries = append(msg.Summaries, &LatencySummary{
+			Function: summary.Function,
+			Count:    int64(summary.Count),
+			P50Us:    int64(summary.P50),
+			P95Us:    int64(summary.P95),
+			P99Us:    int64(summary.P99),
+		})
+	}
+	data, err := proto.Marshal(msg)
+	return data, ContentType, err
+}
+
+/*
+FromEvent converts event to its protobuf form.  It returns an error if event's Data can't be represented.
+*/
+func FromEvent(event *cli.AnalyticsEvent) (*Event, error) {
+	pb := &Event{
+		Timestamp:     event.Timestamp,
+		TimestampUnit: event.TimestampUnit,
+		ConsumerId:    event.ConsumerId,
+		RequestId:     event.RequestId,
+		TraceId:       event.TraceId,
+		SpanId:        event.SpanId,
+		ClientIp:      event.ClientIP,
+		UserAgent:     event.UserAgent,
+		Method:        event.Method,
+		Url:           event.Url,
+		Path:          event.Path,
+		Query:         event.Query,
+		Function:      event.Function,
+		ResponseUs:    int64(event.ResponseUS),
+		StatusCode:    int32(event.StatusCode),
+		ErrorMessage:  event.ErrorMessage,
+		ErrorType:     event.ErrorType,
+		RequestBytes:  event.RequestBytes,
+		ResponseBytes: event.ResponseBytes,
+		SampleRate:    event.SampleRate,
+		Hostname:      event.Hostname,
+		ServiceName:   event.ServiceName,
+		Environment:   event.Environment,
+		Version:       event.Version,
+		Kind:          string(event.Kind),
+	}
+	if len(event.Data) > 0 {
+		data, err := structpb.NewStruct(event.Data)
+		if err != nil {
+			// structpb only knows the basic Go types, e.g. not []string.  Anything JSON serializable can be put into
+			// a form it does know by a trip through JSON
+			data, err = viaJSON(event.Data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pb.Data = data
+	}
+	return pb, nil
+}
+
+// Convert data to a Struct by way of JSON
+func viaJSON(data map[string]interface{}) (*structpb.Struct, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var plain map[string]interface{}
+	if err := json.Unmarshal(encoded, &plain); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(plain)
+}
+
+var _ cli.BatchCodec = Codec{}
@@ -0,0 +1,59 @@
+package apinalytics_client
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+QueryMode says what middleware does with request query strings.
+*/
+type QueryMode int
+
+const (
+	// QueryKeep reports query strings as they are.  The default
+	QueryKeep QueryMode = iota
+	// QueryDrop reports no query strings, in Query or in Url
+	QueryDrop
+	// QueryRedact reports the names of query parameters but not their values
+	QueryRedact
+)
+
+/*
+WithQueryMode sets what the middleware does with query strings, e.g. QueryDrop to keep them out of apinalytics
+entirely.
+*/
+func WithQueryMode(mode QueryMode) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Query = mode
+	}
+}
+
+/*
+SetRequestURL sets event's Url, Path and Query fields from r, treating the query string according to mode.  It is for
+middleware outside this package.
+*/
+func SetRequestURL(event *AnalyticsEvent, r *http.Request, mode QueryMode) {
+	event.Url = r.RequestURI
+	event.Path = r.URL.Path
+	event.Query = r.URL.RawQuery
+	if event.Query == "" || mode == QueryKeep {
+		return
+	}
+
+	if i := strings.IndexByte(event.Url, '?'); i >= 0 {
+		event.Url = event.Url[:i]
+	}
+	if mode == QueryDrop {
+		event.Query = ""
+		return
+	}
+	params := strings.Split(event.Query, "&")
+	for i, param := range params {
+		if eq := strings.IndexByte(param, '='); eq >= 0 {
+			params[i] = param[:eq] + "=" + redacted
+		}
+	}
+	event.Query = strings.Join(params, "&")
+	event.Url += "?" + event.Query
+}
@@ -0,0 +1,18 @@
+package apinalytics_client
+
+/*
+Queuer is implemented by things events can be queued to: Sender, FanoutSender and Router.  Accept a Queuer rather than
+a *Sender so that tests can substitute a fake, and so senders can be wrapped in decorators without changing call sites.
+*/
+type Queuer interface {
+	// Queue queues an event for delivery
+	Queue(event *AnalyticsEvent)
+	// Close stops accepting events and delivers any already queued
+	Close()
+}
+
+var (
+	_ Queuer = (*Sender)(nil)
+	_ Queuer = (*FanoutSender)(nil)
+	_ Queuer = (*Router)(nil)
+)
@@ -0,0 +1,51 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+SetBatchSize changes the maximum number of events sent in a single POST, as set by WithBatchSize, without losing
+queued events.  It is safe to call at any time.  The change takes effect once the background goroutine has finished
+any send in progress.
+*/
+func (sender *Sender) SetBatchSize(size int) {
+	if size <= 0 {
+		return
+	}
+	sender.configure(func() {
+		sender.batchSize = size
+	})
+}
+
+/*
+SetFlushInterval changes how often partial batches are sent, as set by WithFlushInterval.  Zero reverts to sending as
+soon as the queue is empty.  It is safe to call at any time.  The change takes effect once the background goroutine
+has finished any send in progress.
+*/
+func (sender *Sender) SetFlushInterval(interval time.Duration) {
+	sender.configure(func() {
+		sender.setFlushInterval(interval)
+	})
+}
+
+// Pass a change to settings owned by the background goroutine for it to make.  Changes are made in order
+func (sender *Sender) configure(fn func()) {
+	select {
+	case sender.reconfigure <- fn:
+	case <-sender.done:
+		// Closed, so there's nothing to reconfigure
+	}
+}
+
+// Replace the flush ticker.  Only called from the background goroutine
+func (sender *Sender) setFlushInterval(interval time.Duration) {
+	if sender.ticker != nil {
+		sender.ticker.Stop()
+		sender.ticker = nil
+	}
+	sender.flushInterval = interval
+	if interval > 0 {
+		sender.ticker = sender.clock.NewTicker(interval)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Package redis contains a go-redis (https://github.com/redis/go-redis) hook for reporting Redis commands to
+Apinalytics, so you can see cache latency next to API latency.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	rdb.AddHook(NewHook(sender))
+
+Events have Method set to the command name, e.g. "GET", and Function to the pattern of the command's first key, with
+the parts of the key that look like IDs replaced by *, so "user:1234:profile" is reported as "user:*:profile".
+Commands without keys, e.g. PING, SELECT or PUBLISH, have an empty Function.  StatusCode is 200 if the command
+succeeded, 404 if it found nothing (redis.Nil) and 500 if it failed.  Commands in a pipeline are reported
+individually, each with the duration of the whole pipeline.
+*/
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/*
+Hook is a redis.Hook that reports commands to Apinalytics.  Create one with NewHook.
+*/
+type Hook struct {
+	config cli.MiddlewareConfig
+}
+
+/*
+NewHook creates a Hook that reports commands to sender.
+
+The hook takes options from the apinalytics_client package, e.g. WithRequestSampleRate, WithErrorsOnly, WithApdex or
+WithClock.  Commands have no HTTP request or path, so options that need one, such as WithRequestID,
+WithRequestCallback, WithExcludedPaths and WithRouteTags, have no effect.
+*/
+func NewHook(sender cli.Queuer, options ...cli.MiddlewareOption) *Hook {
+	h := &Hook{config: cli.MiddlewareConfig{Sender: sender}}
+	h.config.Apply(options...)
+	return h
+}
+
+/*
+DialHook implements redis.Hook.  Connections aren't reported.
+*/
+func (h *Hook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+/*
+ProcessHook implements redis.Hook.
+*/
+func (h *Hook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		start := h.config.Now()
+		err := next(ctx, cmd)
+		h.report(cmd, start)
+		return err
+	}
+}
+
+/*
+ProcessPipelineHook implements redis.Hook.
+*/
+func (h *Hook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		start := h.config.Now()
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			h.report(cmd, start)
+		}
+		return err
+	}
+}
+
+// Report a command that started at start
+func (h *Hook) report(cmd goredis.Cmder, start time.Time) {
+	event := &cli.AnalyticsEvent{
+		Method:     strings.ToUpper(cmd.Name()),
+		Function:   keyPattern(cmd),
+		StatusCode: 200,
+	}
+	if err := cmd.Err(); errors.Is(err, goredis.Nil) {
+		event.StatusCode = 404
+	} else if err != nil {
+		event.StatusCode = 500
+		cli.SetError(event, err)
+	}
+	h.config.Finish(event, start)
+	h.config.Report(event)
+}
+
+// Parts of keys that look like IDs: numbers, UUIDs and long hex strings
+var idPart = regexp.MustCompile(`^(?:\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// The pattern of the command's first key, or "" if it has none
+func keyPattern(cmd goredis.Cmder) string {
+	args := cmd.Args()
+	pos := firstKeyPos(cmd.Name(), args)
+	if pos == 0 || pos >= len(args) {
+		return ""
+	}
+	key, ok := args[pos].(string)
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(key, ":")
+	for i, part := range parts {
+		if idPart.MatchString(part) {
+			parts[i] = "*"
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// Commands that take no keys, or whose arguments are channels, scripts or settings rather than keys
+var keyless = map[string]bool{
+	"acl": true, "asking": true, "auth": true, "bgrewriteaof": true, "bgsave": true, "client": true, "cluster": true,
+	"command": true, "config": true, "dbsize": true, "debug": true, "discard": true, "echo": true, "exec": true,
+	"failover": true, "flushall": true, "flushdb": true, "function": true, "hello": true, "info": true,
+	"lastsave": true, "latency": true, "lolwut": true, "module": true, "monitor": true, "multi": true, "ping": true,
+	"psubscribe": true, "publish": true, "pubsub": true, "punsubscribe": true, "quit": true, "randomkey": true,
+	"readonly": true, "readwrite": true, "replicaof": true, "role": true, "save": true, "scan": true, "script": true,
+	"select": true, "shutdown": true, "slaveof": true, "slowlog": true, "spublish": true, "ssubscribe": true,
+	"subscribe": true, "sunsubscribe": true, "swapdb": true, "sync": true, "time": true, "unsubscribe": true,
+	"unwatch": true, "wait": true, "waitaof": true,
+}
+
+// The position in args of the first key of the command called name, or 0 if it has none.  Most commands start with a
+// key; the rest are listed here, as in Redis's COMMAND output
+func firstKeyPos(name string, args []interface{}) int {
+	name = strings.ToLower(name)
+	if keyless[name] {
+		return 0
+	}
+	arg := func(i int) string {
+		if i >= len(args) {
+			return ""
+		}
+		// Numbers, e.g. EVAL's number of keys, are passed as ints
+		return strings.ToLower(fmt.Sprint(args[i]))
+	}
+	switch name {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		// The script or function, the number of keys, then the keys
+		if n := arg(2); n == "" || n == "0" {
+			return 0
+		}
+		return 3
+	case "memory":
+		if arg(1) == "usage" {
+			return 2
+		}
+		return 0
+	case "object":
+		return 2
+	case "xread", "xreadgroup":
+		for i := 1; i < len(args)-1; i++ {
+			if arg(i) == "streams" {
+				return i + 1
+			}
+		}
+		return 0
+	}
+	return 1
+}
+
+var _ goredis.Hook = (*Hook)(nil)
@@ -0,0 +1,75 @@
+package apinalytics_client
+
+import (
+	"net/http"
+	"sync"
+)
+
+/*
+SenderRegistry lazily creates and caches one Sender per application, for services such as multi-tenant gateways that
+report events on behalf of many applications.  The Senders share one HTTP transport, and so one pool of connections
+to apinalytics.  Create one with NewSenderRegistry.
+*/
+type SenderRegistry struct {
+	url     string
+	options []Option
+	lock    sync.Mutex
+	senders map[registryKey]*Sender
+	closed  bool
+}
+
+type registryKey struct {
+	applicationId string
+	writeKey      string
+}
+
+/*
+NewSenderRegistry creates a SenderRegistry whose Senders post to url and are created with options.  Unless options
+include WithHTTPClient the Senders share an HTTP client with its own transport.
+*/
+func NewSenderRegistry(url string, options ...Option) *SenderRegistry {
+	client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	return &SenderRegistry{
+		url: url,
+		// Later options win, so any WithHTTPClient in options replaces the shared client
+		options: append([]Option{WithHTTPClient(client)}, options...),
+		senders: make(map[registryKey]*Sender),
+	}
+}
+
+/*
+Get returns the Sender for applicationId and writeKey, creating it if need be.  It is safe to call concurrently.
+
+Once the registry is closed Get returns closed Senders, which discard any events queued to them.
+*/
+func (registry *SenderRegistry) Get(applicationId, writeKey string) *Sender {
+	key := registryKey{applicationId: applicationId, writeKey: writeKey}
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if sender, ok := registry.senders[key]; ok {
+		return sender
+	}
+	sender := NewSender(applicationId, writeKey, registry.url, registry.options...)
+	if registry.closed {
+		sender.Close()
+		return sender
+	}
+	registry.senders[key] = sender
+	return sender
+}
+
+/*
+Close closes every Sender in the registry, in parallel, and waits for their queued events to be sent.
+*/
+func (registry *SenderRegistry) Close() {
+	registry.lock.Lock()
+	registry.closed = true
+	senders := make([]Queuer, 0, len(registry.senders))
+	for _, sender := range registry.senders {
+		senders = append(senders, sender)
+	}
+	registry.lock.Unlock()
+
+	closeAll(senders)
+}
@@ -0,0 +1,35 @@
+package apinalytics_client
+
+import (
+	"net/http"
+)
+
+// DefaultRequestIDHeaders are the headers WithRequestID looks in if it isn't given any.
+var DefaultRequestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
+/*
+WithRequestID makes the middleware set each event's RequestId from the first of the named request headers that is
+present, so the event can be matched up with your application's logs for the same request.  With no names it uses
+DefaultRequestIDHeaders.
+*/
+func WithRequestID(names ...string) MiddlewareOption {
+	if len(names) == 0 {
+		names = DefaultRequestIDHeaders
+	}
+	return func(c *MiddlewareConfig) {
+		c.RequestID = names
+	}
+}
+
+/*
+RequestID returns the value of the first of the named headers that is present in header, or "" if none are.  It is
+for middleware outside this package.
+*/
+func RequestID(header http.Header, names []string) string {
+	for _, name := range names {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package apinalytics_client
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+RetryPolicy controls how the Sender retries a batch of events that failed to send.
+
+Network errors, 5xx responses and 429 Too Many Requests responses from apinalytics are retried, as are Transport
+errors marked Temporary.  Other failures, such as a 4xx response, are not.  The delay before retry n is
+InitialBackoff * Multiplier^(n-1), capped at MaxBackoff, then varied randomly by up to +/- Jitter of itself so that
+many senders don't retry in lock step.  If apinalytics sends a Retry-After header with a 429 or 503 response the
+sender waits at least that long before its next POST.
+
+The zero RetryPolicy makes a single attempt and never retries.
+*/
+type RetryPolicy struct {
+	// Maximum number of attempts to send a batch, including the first
+	MaxAttempts int
+	// Delay before the first retry
+	InitialBackoff time.Duration
+	// Upper limit on the delay between retries.  Zero means no limit
+	MaxBackoff time.Duration
+	// Factor the delay grows by after each retry.  Values below 1 are treated as 1
+	Multiplier float64
+	// Fraction of each delay to randomise, from 0 to 1
+	Jitter float64
+}
+
+// DefaultRetryPolicy is a reasonable policy for riding out short network blips and collector restarts.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+/*
+WithRetry makes the sender retry failed batches according to policy.  By default failed batches are not retried.
+
+	sender := NewSender(appId, key, url, WithRetry(DefaultRetryPolicy))
+*/
+func WithRetry(policy RetryPolicy) Option {
+	return func(sender *Sender) {
+		sender.retry = policy
+	}
+}
+
+// Calculate the delay before the next attempt, given the number of attempts made so far
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxBackoff > 0 && delay > float64(policy.MaxBackoff) {
+		delay = float64(policy.MaxBackoff)
+	}
+
+	jitter := math.Min(math.Max(policy.Jitter, 0), 1)
+	delay += delay * jitter * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
+
+// Decide whether a failed POST is worth retrying.  Server errors and rate limiting are, as are network errors, failures
+// to get a token and an open circuit.  Anything else returned by apinalytics, or a failure to build the request, means
+// the request itself is bad and will fail again
+func retryable(err error) bool {
+	if err == ErrCircuitOpen {
+		return true
+	}
+	var tokenErr *tokenError
+	if errors.As(err, &tokenErr) || isTemporary(err) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Parse the value of a Retry-After header, which is either a number of seconds or an HTTP date, received at now
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package apinalytics_client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+func TestRetryBackoffUsesClock(t *testing.T) {
+	collector := testsender.NewFakeCollector("app", "key")
+	defer collector.Close()
+	clock := testsender.NewFakeClock(time.Unix(1000, 0))
+	sender := cli.NewSender("app", "key", collector.URL, cli.WithClock(clock), cli.WithFlushInterval(time.Hour),
+		cli.WithRetry(cli.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}))
+	defer sender.Close()
+
+	collector.FailNext(1, 503)
+	sender.Queue(&cli.AnalyticsEvent{Method: "GET", Url: "/a", StatusCode: 200})
+	flushed := make(chan error, 1)
+	go func() { flushed <- sender.Flush(context.Background()) }()
+
+	// The retry waits for the fake clock, not an hour of real time
+	if collector.WaitForEvents(1, 100*time.Millisecond) {
+		t.Fatalf("Retried before the clock was advanced")
+	}
+	for !collector.WaitForEvents(1, 10*time.Millisecond) {
+		clock.Advance(time.Hour)
+	}
+	if err := <-flushed; err != nil {
+		t.Errorf("Expected the retry to succeed, got %v", err)
+	}
+	if requests := collector.Requests(); requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
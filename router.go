@@ -0,0 +1,90 @@
+package apinalytics_client
+
+import "reflect"
+
+/*
+Router dispatches events to different Queuers, usually Senders with different URLs, application IDs or write keys,
+according to predicates over the events.  For example, to send events from internal consumers to a staging project:
+
+	router := NewRouter(production).Route(func(event *AnalyticsEvent) bool {
+		return strings.HasPrefix(event.ConsumerId, "internal-")
+	}, staging)
+
+Create a Router with NewRouter and add routes with Route before queueing any events.
+*/
+type Router struct {
+	routes   []route
+	fallback Queuer
+}
+
+type route struct {
+	match  func(event *AnalyticsEvent) bool
+	sender Queuer
+}
+
+/*
+NewRouter creates a Router that sends events matching none of its routes to fallback.  If fallback is nil such
+events are discarded.
+*/
+func NewRouter(fallback Queuer) *Router {
+	return &Router{fallback: fallback}
+}
+
+/*
+Route adds a route sending events for which match returns true to sender.  Routes are tried in the order they were
+added and the first match wins.  Route returns the router so calls can be chained.
+*/
+func (router *Router) Route(match func(event *AnalyticsEvent) bool, sender Queuer) *Router {
+	router.routes = append(router.routes, route{match: match, sender: sender})
+	return router
+}
+
+/*
+Queue queues event to the sender of the first route it matches, or to the fallback sender.
+*/
+func (router *Router) Queue(event *AnalyticsEvent) {
+	if event == nil {
+		return
+	}
+	for _, r := range router.routes {
+		if r.match(event) {
+			r.sender.Queue(event)
+			return
+		}
+	}
+	if router.fallback != nil {
+		router.fallback.Queue(event)
+	}
+}
+
+/*
+Close closes every sender the router routes to, in parallel, and waits for their queued events to be sent.  A sender
+used by several routes is closed once, unless its type isn't comparable, e.g. a struct holding a map or a func type,
+as then there's no telling two of them apart; route to a pointer to such a Queuer instead.
+*/
+func (router *Router) Close() {
+	var senders []Queuer
+	add := func(sender Queuer) {
+		if sender == nil {
+			return
+		}
+		for _, s := range senders {
+			if sameQueuer(s, sender) {
+				return
+			}
+		}
+		senders = append(senders, sender)
+	}
+	for _, r := range router.routes {
+		add(r.sender)
+	}
+	add(router.fallback)
+	closeAll(senders)
+}
+
+// Whether a and b are the same Queuer.  Comparing interfaces holding values of a type that isn't comparable panics, so
+// those are never the same
+func sameQueuer(a, b Queuer) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta.Comparable() && a == b
+}
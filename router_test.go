@@ -0,0 +1,32 @@
+package apinalytics_client_test
+
+import (
+	"testing"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	"github.com/apinalytics/apinalytics_client/testsender"
+)
+
+// A Queuer whose type isn't comparable
+type funcQueuer func(event *cli.AnalyticsEvent)
+
+func (queue funcQueuer) Queue(event *cli.AnalyticsEvent) { queue(event) }
+func (queue funcQueuer) Close()                          {}
+
+func TestRouterCloseWithUncomparableQueuer(t *testing.T) {
+	fallback := testsender.NewRecordingSender()
+	var queued int
+	other := funcQueuer(func(event *cli.AnalyticsEvent) { queued++ })
+	isJob := func(event *cli.AnalyticsEvent) bool { return event.Kind == cli.KindJob }
+	router := cli.NewRouter(fallback).Route(isJob, other).Route(isJob, other).Route(isJob, fallback)
+
+	router.Queue(&cli.AnalyticsEvent{Kind: cli.KindJob, Function: "nightly-report"})
+	router.Close()
+
+	if queued != 1 {
+		t.Errorf("Expected 1 event queued to the func Queuer, got %d", queued)
+	}
+	if !fallback.Closed() {
+		t.Errorf("Fallback not closed")
+	}
+}
@@ -0,0 +1,72 @@
+package apinalytics_client
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// The runtime/metrics sample for the CPU time used by the process
+const cpuMetric = "/cpu/classes/total:cpu-seconds"
+
+/*
+WithRuntimeMetrics makes the sender queue an event describing the Go runtime every interval, so you can see the
+health of your hosts alongside your API metrics.  The events have Kind KindMetric, Function "runtime" and in Data
+
+	goroutines         - number of goroutines
+	heap_alloc_bytes   - bytes allocated and not yet freed
+	heap_sys_bytes     - bytes of heap obtained from the OS
+	gc_count           - garbage collections completed
+	gc_pause_total_us  - total time spent in GC stop-the-world pauses, in microseconds
+	cpu_seconds        - estimated total CPU time used by the process, in seconds
+
+Like heartbeats they bypass filters and sampling, and are dropped if the queue is full.
+*/
+func WithRuntimeMetrics(interval time.Duration) Option {
+	return func(sender *Sender) {
+		sender.runtimeEvery = interval
+	}
+}
+
+// Queue runtime metrics every interval until the sender is closed
+func (sender *Sender) reportRuntime() {
+	ticker := sender.clock.NewTicker(sender.runtimeEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sender.quit:
+			return
+		case now := <-ticker.C():
+			select {
+			case sender.channel <- runtimeEvent(now):
+			default:
+				sender.drop()
+			}
+		}
+	}
+}
+
+// Build an event describing the runtime
+func runtimeEvent(now time.Time) *AnalyticsEvent {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	event := &AnalyticsEvent{
+		Kind:     KindMetric,
+		Function: "runtime",
+		Data: map[string]interface{}{
+			"goroutines":        runtime.NumGoroutine(),
+			"heap_alloc_bytes":  mem.HeapAlloc,
+			"heap_sys_bytes":    mem.HeapSys,
+			"gc_count":          mem.NumGC,
+			"gc_pause_total_us": mem.PauseTotalNs / 1000,
+		},
+	}
+	cpu := []metrics.Sample{{Name: cpuMetric}}
+	metrics.Read(cpu)
+	if cpu[0].Value.Kind() == metrics.KindFloat64 {
+		event.Data["cpu_seconds"] = cpu[0].Value.Float64()
+	}
+	event.SetTime(now)
+	return event
+}
@@ -0,0 +1,64 @@
+package apinalytics_client
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+	"sync/atomic"
+)
+
+/*
+WithSampleRate makes the sender report only a fraction of the events queued to it, e.g. 0.05 for 5%.  This is
+useful for busy APIs where every event isn't needed.
+
+Sampling is deterministic: whether an event is kept depends only on its contents, so the same event is always
+treated the same way.  Each event sent is stamped with the rate in its SampleRate field, so counts can be scaled up
+by the server.  An event queued with SampleRate already set is sampled at that rate instead, which lets middleware
+override the sender's rate.
+
+Rates of 0 or 1 and above send every event.
+*/
+func WithSampleRate(rate float64) Option {
+	return func(sender *Sender) {
+		sender.SetSampleRate(rate)
+	}
+}
+
+/*
+SetSampleRate changes the fraction of events reported, as set by WithSampleRate.  It is safe to call while events are
+being queued, e.g. from an admin endpoint during a load spike.
+*/
+func (sender *Sender) SetSampleRate(rate float64) {
+	atomic.StoreUint64(&sender.sampleRate, math.Float64bits(rate))
+}
+
+// Decide whether to keep an event, stamping it with the sample rate used if we are sampling
+func (sender *Sender) sample(event *AnalyticsEvent) bool {
+	if event == nil {
+		return true
+	}
+	rate := event.SampleRate
+	if rate <= 0 {
+		rate = math.Float64frombits(atomic.LoadUint64(&sender.sampleRate))
+	}
+	if rate <= 0 || rate >= 1 {
+		event.SampleRate = 0
+		return true
+	}
+	event.SampleRate = rate
+	return sampleKey(event) < rate
+}
+
+// Map an event to a number in [0, 1] derived from its contents
+func sampleKey(event *AnalyticsEvent) float64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, 128)
+	buf = strconv.AppendInt(buf, event.Timestamp, 10)
+	buf = append(buf, event.ConsumerId...)
+	buf = append(buf, event.Method...)
+	buf = append(buf, event.Url...)
+	buf = strconv.AppendInt(buf, int64(event.ResponseUS), 10)
+	buf = strconv.AppendInt(buf, int64(event.StatusCode), 10)
+	h.Write(buf)
+	return float64(h.Sum64()) / math.MaxUint64
+}
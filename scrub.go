@@ -0,0 +1,120 @@
+package apinalytics_client
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Text that replaces redacted values
+const redacted = "REDACTED"
+
+// DefaultScrubKeys are the query parameter and Data keys whose values DefaultScrubber redacts.
+var DefaultScrubKeys = []string{
+	"token", "access_token", "refresh_token", "id_token",
+	"key", "api_key", "apikey", "write_key",
+	"password", "passwd", "pwd", "secret", "client_secret",
+	"authorization", "auth", "signature", "sig",
+}
+
+// EmailPattern matches email addresses, including those with a URL encoded @.
+var EmailPattern = regexp.MustCompile(`[A-Za-z0-9._+\-]+(?:@|%40)[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+
+/*
+Scrubber redacts sensitive information, such as tokens in query strings or email addresses, from events before they
+are sent.  Create one with NewScrubber or DefaultScrubber and add it to a Sender with WithScrubber.
+*/
+type Scrubber struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+}
+
+/*
+NewScrubber creates a Scrubber that redacts
+
+  - the values of query parameters in Url, and values in Data, whose keys are in keys.  Keys are matched
+    case-insensitively.
+  - anything matching one of patterns, wherever it appears in Url, Path, Query or a string value in Data.
+*/
+func NewScrubber(keys []string, patterns ...*regexp.Regexp) *Scrubber {
+	scrubber := &Scrubber{
+		keys:     make(map[string]bool, len(keys)),
+		patterns: patterns,
+	}
+	for _, key := range keys {
+		scrubber.keys[strings.ToLower(key)] = true
+	}
+	return scrubber
+}
+
+/*
+DefaultScrubber creates a Scrubber that redacts values for the keys in DefaultScrubKeys and email addresses.
+*/
+func DefaultScrubber() *Scrubber {
+	return NewScrubber(DefaultScrubKeys, EmailPattern)
+}
+
+/*
+WithScrubber makes the sender redact sensitive information from every event using scrubber.  Scrubbing happens in the
+background goroutine after any enrichers have run, so values they add are scrubbed too.
+
+	sender := NewSender(appId, key, url, WithScrubber(DefaultScrubber()))
+*/
+func WithScrubber(scrubber *Scrubber) Option {
+	return func(sender *Sender) {
+		sender.scrubber = scrubber
+	}
+}
+
+/*
+Scrub redacts sensitive information from event in place.  A nil Scrubber does nothing.
+*/
+func (scrubber *Scrubber) Scrub(event *AnalyticsEvent) {
+	if scrubber == nil || event == nil {
+		return
+	}
+	event.Url = scrubber.scrubPatterns(scrubber.scrubQuery(event.Url))
+	event.Path = scrubber.scrubPatterns(event.Path)
+	if event.Query != "" {
+		event.Query = strings.TrimPrefix(scrubber.scrubPatterns(scrubber.scrubQuery("?"+event.Query)), "?")
+	}
+	for key, value := range event.Data {
+		if scrubber.keys[strings.ToLower(key)] {
+			event.Data[key] = redacted
+		} else if s, ok := value.(string); ok {
+			event.Data[key] = scrubber.scrubPatterns(s)
+		}
+	}
+}
+
+// Redact the values of sensitive query parameters, leaving the rest of the URL untouched
+func (scrubber *Scrubber) scrubQuery(rawURL string) string {
+	i := strings.IndexByte(rawURL, '?')
+	if i < 0 || len(scrubber.keys) == 0 {
+		return rawURL
+	}
+
+	params := strings.Split(rawURL[i+1:], "&")
+	for j, param := range params {
+		rawKey := param
+		if eq := strings.IndexByte(param, '='); eq >= 0 {
+			rawKey = param[:eq]
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if scrubber.keys[strings.ToLower(key)] {
+			params[j] = rawKey + "=" + redacted
+		}
+	}
+	return rawURL[:i+1] + strings.Join(params, "&")
+}
+
+// Redact anything matching the scrubber's patterns
+func (scrubber *Scrubber) scrubPatterns(value string) string {
+	for _, pattern := range scrubber.patterns {
+		value = pattern.ReplaceAllLiteralString(value, redacted)
+	}
+	return value
+}
@@ -0,0 +1,42 @@
+package apinalytics_client_test
+
+import (
+	"strings"
+	"testing"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+func TestScrubRedactsEverywhere(t *testing.T) {
+	event := &cli.AnalyticsEvent{
+		Url:   "/users/bob@example.com/orders?token=abc123&page=2",
+		Path:  "/users/bob@example.com/orders",
+		Query: "token=abc123&page=2",
+		Data:  map[string]interface{}{"password": "hunter2", "note": "from bob@example.com", "count": 3},
+	}
+	cli.DefaultScrubber().Scrub(event)
+
+	for field, value := range map[string]string{"Url": event.Url, "Path": event.Path, "Query": event.Query} {
+		if strings.Contains(value, "bob@example.com") || strings.Contains(value, "abc123") {
+			t.Errorf("%s wasn't scrubbed: %s", field, value)
+		}
+	}
+	if event.Path != "/users/REDACTED/orders" {
+		t.Errorf("Unexpected Path %s", event.Path)
+	}
+	if event.Query != "token=REDACTED&page=2" {
+		t.Errorf("Unexpected Query %s", event.Query)
+	}
+	if event.Data["password"] != "REDACTED" || event.Data["note"] != "from REDACTED" || event.Data["count"] != 3 {
+		t.Errorf("Unexpected Data %v", event.Data)
+	}
+}
+
+func TestNilScrubberDoesNothing(t *testing.T) {
+	var scrubber *cli.Scrubber
+	event := &cli.AnalyticsEvent{Path: "/users/bob@example.com"}
+	scrubber.Scrub(event)
+	if event.Path != "/users/bob@example.com" {
+		t.Errorf("Unexpected Path %s", event.Path)
+	}
+}
@@ -0,0 +1,30 @@
+package apinalytics_client
+
+import (
+	"runtime"
+)
+
+// SDKVersion is the version of this client library, reported to apinalytics with every batch.
+const SDKVersion = "0.9.0"
+
+// The name this client library reports itself as
+const sdkName = "go"
+
+/*
+SDK identifies the client library that sent a batch, so apinalytics can track which versions are in use.  It is sent
+in the sdk block of batch envelopes, and as "go/<SDKVersion>" in the X-Apinalytics-SDK header of every POST.
+*/
+type SDK struct {
+	// Language of the client library, "go"
+	Name string `json:"name"`
+	// SDKVersion
+	Version string `json:"version"`
+	// Version of Go the client was built with
+	Runtime string `json:"runtime"`
+}
+
+// Identifies this library in batch envelopes
+var sdk = &SDK{Name: sdkName, Version: SDKVersion, Runtime: runtime.Version()}
+
+// Value of the X-Apinalytics-SDK header
+const sdkHeader = sdkName + "/" + SDKVersion
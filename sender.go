@@ -14,52 +14,171 @@ an example html dashboard.
 package apinalytics_client
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	// Server URL
 	// url string = "http://127.0.0.1:7998/1/event/"
-	// The size of the queue to the background goroutine
-	channel_size int = 100
-	// The background routine will send batches of events up to this size
-	send_threshold int = 90
+	// The default size of the queue to the background goroutine
+	default_queue_size int = 100
+	// By default the background routine will send batches of events up to this size
+	default_batch_size int = 90
+	// By default the background routine will hold up to this many events while paused
+	default_pause_buffer int = 10000
+	// By default we fail over to a fallback URL after this many consecutive failures
+	default_failover_threshold int = 3
+	// By default we retry the primary URL this often after failing over
+	default_probe_interval = time.Minute
 )
 
 // AnalyticsEvent records an API call.
 type AnalyticsEvent struct {
-	// Timestamp for this event in seconds since 1 Jan 1970 UTC
+	// Timestamp for this event in seconds since 1 Jan 1970 UTC, or in TimestampUnit if that's set
 	Timestamp int64 `json:"timestamp"`
+	// Unit of Timestamp if it isn't seconds, e.g. "ms".  Set by the Sender, see WithTimestampUnit
+	TimestampUnit string `json:"timestamp_unit,omitempty"`
+	// Precise time of the event, from which the Sender works out Timestamp if it uses a unit smaller than seconds.
+	// Not sent itself.  See SetTime
+	Time time.Time `json:"-"`
+	// What the event records.  Empty for an API call.  See EventKind
+	Kind EventKind `json:"kind,omitempty"`
 	// Identifier for the API consumer
 	ConsumerId string `json:"consumer_id"`
+	// Identifier for the request, e.g. from an X-Request-Id header, for matching the event with application logs
+	RequestId string `json:"request_id,omitempty"`
+	// W3C trace ID of the distributed trace the request was part of, from its traceparent header
+	TraceId string `json:"trace_id,omitempty"`
+	// W3C ID of the span that made the request, from its traceparent header
+	SpanId string `json:"span_id,omitempty"`
+	// IP address of the client that made the request
+	ClientIP string `json:"client_ip,omitempty"`
+	// User-Agent header of the request
+	UserAgent string `json:"user_agent,omitempty"`
 	// HTTP Method used ("GET", "POST", etc.)
 	Method string `json:"method"`
 	// Url used (including parameters)
 	Url string `json:"url"`
+	// Path part of Url
+	Path string `json:"path,omitempty"`
+	// Query string part of Url, without the ?
+	Query string `json:"query,omitempty"`
 	// Name of the function invoked.
-	Function string `json:"function",omitempty`
+	Function string `json:"function"`
 	// API response time in microseconds
 	ResponseUS int `json:"response_us"`
 	// HTTP status code
 	StatusCode int `json:"status_code"`
-	// Arbitrary key, value pairs to report.  Not yet implemented
-	Data map[string]string `json:"data",omitempty`
+	// Why the request failed, if the handler said.  See RecordError
+	ErrorMessage string `json:"error_message,omitempty"`
+	// Go type of the error the request failed with, e.g. "*fs.PathError"
+	ErrorType string `json:"error_type,omitempty"`
+	// Arbitrary key, value pairs to report.  Values must be JSON serializable: strings, numbers, bools, or slices and
+	// maps of them.  See Set
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Size of the request body in bytes, if known
+	RequestBytes int64 `json:"request_bytes,omitempty"`
+	// Size of the response body in bytes
+	ResponseBytes int64 `json:"response_bytes,omitempty"`
+	// Fraction of events like this one that are reported, if they are being sampled.  Zero means all of them.  Set
+	// by the Sender, or set it yourself to override the Sender's sample rate for this event
+	SampleRate float64 `json:"sample_rate,omitempty"`
+	// Host the event was sent from.  Set by the Sender, see EventDefaults
+	Hostname string `json:"hostname,omitempty"`
+	// Name of the service that sent the event.  Set by the Sender, see EventDefaults
+	ServiceName string `json:"service_name,omitempty"`
+	// Deployment environment the event was sent from, e.g. "production".  Set by the Sender, see EventDefaults
+	Environment string `json:"environment,omitempty"`
+	// Version of the service that sent the event.  Set by the Sender, see EventDefaults
+	Version string `json:"version,omitempty"`
+}
+
+/*
+Clone returns a copy of the event, including its Data, that can be modified without affecting the original.  Slices
+and maps in Data are shared with the original, so replace rather than modify them.
+*/
+func (event *AnalyticsEvent) Clone() *AnalyticsEvent {
+	clone := *event
+	if event.Data != nil {
+		clone.Data = make(map[string]interface{}, len(event.Data))
+		for key, value := range event.Data {
+			clone.Data[key] = value
+		}
+	}
+	return &clone
 }
 
 /*
 Sender is used to send events to apinalytics.  Create a sender using NewSender.
 */
 type Sender struct {
+	stats         counters // Delivery statistics.  First for 64-bit alignment of its atomic counters
 	applicationId string
 	writeKey      string
 	url           string               // The url to post events too, including project details
+	endpoints     endpoints            // Chooses between url and any fallback URLs
 	events        []*AnalyticsEvent    // For batching events as we pull them off the channel
 	count         int                  // Number of events batched and ready to send
 	channel       chan *AnalyticsEvent // For queuing events to the background
-	done          chan bool            // For clean exiting
+	quit          chan struct{}        // Closed when the sender is closed
+	closeOnce     sync.Once            // Makes sure quit is only closed once
+	done          chan bool            // Closed when the background goroutine exits
+	ctx           context.Context      // Cancelled to abandon sends in progress when Close times out
+	cancel        context.CancelFunc
+	flushes       chan chan error         // Requests to the background goroutine to send everything queued
+	resumed       chan struct{}           // Tells the background goroutine that the sender has been resumed
+	paused        int32                   // 1 while the sender is paused
+	queueSize     int                     // Capacity of channel
+	batchSize     int                     // Maximum number of events sent in one POST
+	pauseBuffer   int                     // Maximum number of events held while paused
+	flushInterval time.Duration           // Send partial batches this often.  Zero sends as soon as the channel is idle
+	ticker        Ticker                  // Ticks every flushInterval.  nil if there is no flush interval
+	clock         Clock                   // Tells the time and makes ticker
+	reconfigure   chan func()             // Changes to settings owned by the background goroutine
+	retry         RetryPolicy             // How failed batches are retried
+	errorHandler  ErrorHandler            // Told about batches that could not be sent
+	deadLetter    func([]*AnalyticsEvent) // Given batches that could not be sent
+	client        *http.Client            // Used to POST events
+	transport     Transport               // Delivers batches instead of POSTing them.  May be nil
+	dropPolicy    DropPolicy              // What Queue does when channel is full
+	gzip          bool                    // Compress the body of each POST
+	codec         Codec                   // Encodes batches of events for the wire
+	spool         Spool                   // Holds batches that couldn't be sent until apinalytics is reachable
+	breaker       *circuitBreaker         // Stops us trying to send while apinalytics is down.  May be nil
+	sampleRate    uint64                  // Fraction of events to send, as float64 bits.  Zero sends all
+	filters       []Filter                // Events must pass all of these to be queued
+	enrichers     []Enricher              // Applied to each event before it is batched
+	scrubber      *Scrubber               // Redacts sensitive values from events after enrichment.  May be nil
+	normalizer    *URLNormalizer          // Rewrites IDs in event URLs to placeholders.  May be nil
+	hashConsumer  func(string) string     // Hashes each event's ConsumerId.  May be nil
+	defaults      EventDefaults           // Stamped on each event
+	timeUnit      TimestampUnit           // Unit Timestamps are sent in
+	strict        bool                    // Discard events that fail Validate
+	summaries     bool                    // Add LatencySummaries to batch envelopes
+	heartbeat     time.Duration           // How often to queue heartbeats.  Zero for none
+	runtimeEvery  time.Duration           // How often to queue runtime metrics.  Zero for none
+	dedup         *deduper                // Collapses duplicate events.  May be nil
+	ordered       bool                    // Never send a batch while older ones are spooled
+	maxPayload    int                     // Largest POST body we'll send, in bytes.  Zero for no limit
+	signer        Signer                  // Signs each POST.  May be nil
+	headers       http.Header             // Extra headers added to each POST
+	headerFuncs   []func() http.Header    // Provide extra headers for each POST
+	tokenSource   func() (string, error)  // Provides a bearer token for each POST.  May be nil
+	tlsConfig     *tls.Config             // Applied to client's transport.  May be nil
+	envelope      bool                    // Send batches wrapped in a Batch rather than as a bare list of events
+	batchSent     batchSentHook           // Told about each batch sent.  May be nil
+	batchFailed   batchFailedHook         // Told about each batch that couldn't be sent.  May be nil
+	parser        ResponseParser          // Finds events apinalytics rejected from a batch it accepted
+	syncTimeout   time.Duration           // Queue waits this long for each event to be sent.  Zero to not wait
 }
 
 /*
@@ -70,17 +189,49 @@ This creates a background goroutine to aggregate and send your events.
  applicationId - Identifies the application generating the events.
  writeKey      - Your apinalytics write key
  url           - URL of the Apinalytics service (usually http://apinalytics.tanktop.tv)
+ options       - Optional settings to tune the sender, e.g. WithBatchSize(500), WithQueueSize(10000)
 */
-func NewSender(applicationId, writeKey, url string) *Sender {
+func NewSender(applicationId, writeKey, url string, options ...Option) *Sender {
 	sender := &Sender{
 		applicationId: applicationId,
 		writeKey:      writeKey,
-		channel:       make(chan *AnalyticsEvent, channel_size),
+		quit:          make(chan struct{}),
 		done:          make(chan bool),
+		flushes:       make(chan chan error),
+		resumed:       make(chan struct{}, 1),
+		reconfigure:   make(chan func(), 8),
+		queueSize:     default_queue_size,
+		batchSize:     default_batch_size,
+		pauseBuffer:   default_pause_buffer,
+		errorHandler:  logError,
+		client:        http.DefaultClient,
+		codec:         JSONCodec{},
+		parser:        MultiStatusParser{},
+		clock:         realClock{},
+		defaults:      EventDefaults{Hostname: hostname()},
+		endpoints: endpoints{
+			threshold:     default_failover_threshold,
+			probeInterval: default_probe_interval,
+		},
+	}
+	for _, option := range options {
+		option(sender)
 	}
+	if sender.tlsConfig != nil {
+		sender.client = sender.clientWithTLS()
+	}
+	sender.ctx, sender.cancel = context.WithCancel(context.Background())
+	sender.channel = make(chan *AnalyticsEvent, sender.queueSize)
 	sender.url = url
+	sender.endpoints.urls = append([]string{url}, sender.endpoints.urls...)
 	sender.reset()
 	go sender.run()
+	if sender.heartbeat > 0 {
+		go sender.beat()
+	}
+	if sender.runtimeEvery > 0 {
+		go sender.reportRuntime()
+	}
 	return sender
 }
 
@@ -91,32 +242,196 @@ info can be anything that is JSON serializable.  Events are immediately queued t
 background routine will send everything that's queued to it in a batch, then wait for new data.
 
 The upshot is that if you send events slowly they will be sent immediately and individually, but if you send events quickly they will be batched
+
+If the sender was created WithFlushInterval events are instead held until a full batch is ready or the interval passes.
+
+If the queue is full Queue blocks until there is space, unless the sender was created with a DropPolicy that allows
+events to be discarded.
+
+Events queued after the sender is closed are discarded and counted in Stats().Dropped.  Events queued while Close is
+in progress may be sent or discarded.
 */
 func (sender *Sender) Queue(event *AnalyticsEvent) {
-	sender.channel <- event
+	sender.QueueContext(context.Background(), event)
+}
+
+/*
+QueueContext queues an event like Queue, but if the queue is full and the sender's DropPolicy is Block it gives up
+when ctx is done, discarding the event and returning ctx.Err().  Pass a request's context, or one with a short
+deadline, so a slow apinalytics can't hold up your handlers indefinitely.
+
+With DropPolicy DropNewest, QueueContext returns ErrQueueFull if the event was discarded.  Events discarded by
+WithFilter or sampling are not errors.
+
+Once the sender is closed events are discarded, counted as dropped, and QueueContext returns ErrSenderClosed.
+
+In synchronous mode QueueContext also returns any error sending the event.
+*/
+func (sender *Sender) QueueContext(ctx context.Context, event *AnalyticsEvent) error {
+	if err := sender.enqueue(ctx, event); err != nil || sender.syncTimeout == 0 {
+		return err
+	}
+	return sender.sendNow(ctx)
+}
+
+// Queue an event to the background goroutine
+func (sender *Sender) enqueue(ctx context.Context, event *AnalyticsEvent) error {
+	if event == nil || !sender.filter(event) || !sender.sample(event) {
+		return nil
+	}
+	select {
+	case <-sender.quit:
+		sender.drop()
+		return ErrSenderClosed
+	default:
+	}
+
+	switch sender.dropPolicy {
+	case DropNewest:
+		select {
+		case sender.channel <- event:
+		default:
+			sender.drop()
+			return ErrQueueFull
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case sender.channel <- event:
+				return nil
+			default:
+				// Make room by discarding the event at the head of the queue.  The background goroutine may beat us
+				// to it, in which case there's nothing to discard and we simply try again
+				select {
+				case <-sender.channel:
+					sender.drop()
+				default:
+				}
+			}
+		}
+
+	default:
+		select {
+		case sender.channel <- event:
+		case <-ctx.Done():
+			sender.drop()
+			return ctx.Err()
+		case <-sender.quit:
+			sender.drop()
+			return ErrSenderClosed
+		}
+	}
+	return nil
+}
+
+/*
+Flush sends every event queued so far without closing the sender.
+
+Flush blocks until the background goroutine has posted the events, or until ctx expires.  It returns the first error
+encountered sending the events, ctx.Err() if the context expired first, ErrSenderClosed if the sender has been
+closed, or ErrPaused if the sender is paused.
+*/
+func (sender *Sender) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+	select {
+	case sender.flushes <- reply:
+	case <-sender.done:
+		return ErrSenderClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 /*
-Close the sender and wait for queued events to be sent
+Close the sender and wait for queued events to be sent.  Closing a sender more than once has no further effect.
 */
 func (sender *Sender) Close() {
-	// Closing the channel signals the background thread to exit
-	close(sender.channel)
+	sender.CloseContext(context.Background())
+}
+
+/*
+CloseContext closes the sender and waits for queued events to be sent, giving up when ctx expires.
+
+If ctx expires first any send in progress is abandoned, events not yet sent are passed to the error handler, and
+ctx.Err() is returned.  Use this rather than Close during shutdown so a dead apinalytics endpoint can't hang your
+process.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := sender.CloseContext(ctx)
+*/
+func (sender *Sender) CloseContext(ctx context.Context) error {
+	// Closing quit stops new events being queued and signals the background thread to exit
+	sender.closeOnce.Do(func() {
+		close(sender.quit)
+	})
 	// Wait for the background thread to signal it has flushed all events and exited
-	<-sender.done
+	select {
+	case <-sender.done:
+		sender.cancel()
+		return nil
+	case <-ctx.Done():
+		// Abandon whatever the background thread is doing.  It fails the remaining events quickly and exits
+		sender.cancel()
+		return ctx.Err()
+	}
 }
 
-// Add an event to the map that's used to batch events
+// Add an event to the map that's used to batch events, sending if we have a full batch
 func (sender *Sender) add(event *AnalyticsEvent) bool {
+	if !sender.batch(event) {
+		return false
+	}
+	if sender.count >= sender.batchSize && !sender.isPaused() {
+		sender.send()
+	}
+	return true
+}
+
+// Add an event to the map that's used to batch events without sending
+func (sender *Sender) batch(event *AnalyticsEvent) bool {
 	if event == nil {
 		// nil event, don't add
 		return false
 	}
+	sender.defaults.apply(event)
+	sender.timeUnit.stamp(event)
+	sender.enrich(event)
+	sender.normalizer.Normalize(event)
+	sender.scrubber.Scrub(event)
+	sender.checkData(event)
+	if sender.strict {
+		if err := event.Validate(); err != nil {
+			sender.drop()
+			sender.errorHandler(err, []*AnalyticsEvent{event})
+			return false
+		}
+	}
+	if sender.hashConsumer != nil {
+		event.ConsumerId = sender.hashConsumer(event.ConsumerId)
+	}
+	if sender.dedup.collapse(event, sender.clock.Now()) {
+		return false
+	}
 	sender.events = append(sender.events, event)
 	sender.count++
-
-	if sender.count > send_threshold {
-		sender.send()
+	atomic.AddInt64(&sender.stats.batched, 1)
+	if sender.count > sender.pauseBuffer && sender.isPaused() {
+		// Paused with too much buffered.  Make room by discarding the oldest event
+		sender.dedup.forget(sender.events[0])
+		sender.events[0] = nil
+		sender.events = sender.events[1:]
+		sender.count--
+		atomic.AddInt64(&sender.stats.batched, -1)
+		sender.drop()
 	}
 	return true
 }
@@ -125,71 +440,321 @@ func (sender *Sender) add(event *AnalyticsEvent) bool {
 func (sender *Sender) reset() {
 	sender.events = make([]*AnalyticsEvent, 0, 10)
 	sender.count = 0
+	atomic.StoreInt64(&sender.stats.batched, 0)
+	sender.dedup.sent(sender.clock.Now())
 }
 
-// Send the events currently in sender.events
-func (sender *Sender) send() {
-	if sender.count == 0 {
-		return
-	}
+// Send the events currently in sender.events, in batches of at most batchSize.  Returns the first error encountered
+func (sender *Sender) send() error {
 	// Whether we can send the events or not, we dump them before exiting this function
 	defer sender.reset()
 
-	// Convert data to JSON
-	data, err := json.Marshal(sender.events)
+	var firstErr error
+	for start := 0; start < sender.count; start += sender.batchSize {
+		end := start + sender.batchSize
+		if end > sender.count {
+			end = sender.count
+		}
+		if err := sender.post(sender.events[start:end]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// POST a batch of events to apinalytics.  A batch that can't be delivered is spooled if the sender has a spool and
+// the failure is temporary, otherwise it is passed to the error handler
+func (sender *Sender) post(events []*AnalyticsEvent) error {
+	if sender.ordered && !sender.replay() {
+		// Older batches are still waiting in the spool, so this one has to wait behind them
+		return sender.hold(events)
+	}
+
+	err := ErrCircuitOpen
+	if sender.breaker.allow(sender.clock.Now()) {
+		err = sender.deliver(events)
+		// Only failures that suggest apinalytics is down count against the circuit
+		sender.breaker.record(err == nil || !retryable(err), sender.clock.Now())
+	}
+	if tooLarge(err) && len(events) > 1 {
+		// Try again in two halves
+		half := len(events) / 2
+		err = sender.post(events[:half])
+		if err2 := sender.post(events[half:]); err == nil {
+			err = err2
+		}
+		return err
+	}
+	if err == nil {
+		// apinalytics is reachable, so this is a good time to send anything spooled while it wasn't
+		sender.replay()
+		return nil
+	}
+	if retryable(err) {
+		return sender.store(err, events)
+	}
+	sender.fail(err, events)
+	return err
+}
+
+// Spool a batch that couldn't be sent because of err, or fail it if there's no spool or it can't be spooled
+func (sender *Sender) store(err error, events []*AnalyticsEvent) error {
+	if sender.spool != nil {
+		spoolErr := sender.spool.Store(events)
+		if spoolErr == nil {
+			sender.stats.failed(err, sender.clock.Now())
+			return err
+		}
+		err = fmt.Errorf("%v.  Couldn't spool events.  %v", err, spoolErr)
+	}
+	sender.fail(err, events)
+	return err
+}
+
+// Spool a batch without trying to send it, because older batches are still spooled.  It hasn't failed, so isn't
+// counted as a failure unless it can't be spooled
+func (sender *Sender) hold(events []*AnalyticsEvent) error {
+	if err := sender.spool.Store(events); err != nil {
+		err = fmt.Errorf("%v.  Couldn't spool events.  %v", errSpoolBacklog, err)
+		sender.fail(err, events)
+		return err
+	}
+	return errSpoolBacklog
+}
+
+// Encode a batch of events and POST it to apinalytics, retrying according to the sender's RetryPolicy
+func (sender *Sender) deliver(events []*AnalyticsEvent) error {
+	// The ID stays the same across retries so apinalytics can discard duplicates
+	batch := sender.newBatch(events)
+	data, contentType, err := sender.encode(batch)
 	if err != nil {
-		log.Printf("Couldn't marshal json for analytics. %v\n", err)
-		return
+		return err
+	}
+	if sender.maxPayload > 0 && len(data) > sender.maxPayload {
+		return ErrPayloadTooLarge
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Hold off if apinalytics has asked us to
+		sender.sleep(sender.stats.throttleDelay(sender.clock.Now()))
+
+		start := sender.clock.Now()
+		var rejected []RejectedEvent
+		rejected, err = sender.transmit(batch, data, contentType)
+		if err == nil {
+			took := sender.clock.Now().Sub(start)
+			retry, rejectedCount := sender.sortRejected(batch.Events, rejected)
+			sender.stats.sent(len(batch.Events)-rejectedCount, took)
+			if sender.batchSent != nil {
+				sender.batchSent(batch.ID, len(batch.Events)-rejectedCount, took)
+			}
+			if len(retry) == 0 {
+				return nil
+			}
+			if attempt >= sender.retry.MaxAttempts || !sender.sleep(sender.retry.backoff(attempt)) {
+				sender.fail(ErrRejected, retry)
+				return nil
+			}
+			// Resend the events apinalytics asked us to retry as a new batch
+			batch = sender.newBatch(retry)
+			if data, contentType, err = sender.encode(batch); err != nil {
+				sender.fail(err, retry)
+				return nil
+			}
+			continue
+		}
+		if sender.fallBack(err) {
+			// Try again in a format apinalytics understands
+			if data, contentType, err = sender.encode(batch); err != nil {
+				return err
+			}
+			continue
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			sender.stats.throttle(statusErr.RetryAfter, sender.clock.Now())
+		}
+		if !retryable(err) || attempt >= sender.retry.MaxAttempts || !sender.sleep(sender.retry.backoff(attempt)) {
+			// We're giving up on this batch
+			return err
+		}
 	}
+}
 
-	req, err := http.NewRequest("POST", sender.url, strings.NewReader(string(data)))
+// Encode a batch of events for the wire, returning the body to POST and its content type
+func (sender *Sender) encode(batch *Batch) ([]byte, string, error) {
+	// JSON unless the sender has another codec.  Use the envelope if asked, and the codec knows how
+	var data []byte
+	var contentType string
+	var err error
+	if batchCodec, ok := sender.codec.(BatchCodec); ok && sender.envelope {
+		data, contentType, err = batchCodec.MarshalBatch(batch)
+	} else {
+		data, contentType, err = sender.codec.Marshal(batch.Events)
+	}
 	if err != nil {
-		log.Printf("Failed to build analytics POST. %v", err)
-		return
+		return nil, "", fmt.Errorf("apinalytics: couldn't marshal events. %v", err)
+	}
+	if sender.gzip {
+		if data, err = compress(data); err != nil {
+			return nil, "", fmt.Errorf("apinalytics: couldn't compress events. %v", err)
+		}
+	}
+	return data, contentType, nil
+}
+
+// Make a single attempt to POST an encoded batch to apinalytics.  If the POST succeeds, returns any events in the batch
+// that apinalytics rejected
+func (sender *Sender) postData(batchID string, data []byte, contentType string) ([]RejectedEvent, error) {
+	req, err := http.NewRequestWithContext(sender.ctx, "POST", sender.endpoints.next(sender.clock.Now()), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// Extra headers first, so they can't replace the ones apinalytics depends on
+	sender.addHeaders(req.Header)
+	req.Header.Set("Content-Type", contentType)
+	if sender.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
 	}
-	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Auth-User", sender.applicationId)
 	req.Header.Set("X-Auth-Key", sender.writeKey)
-	rsp, err := http.DefaultClient.Do(req)
+	req.Header.Set("X-Batch-Id", batchID)
+	req.Header.Set("X-Apinalytics-SDK", sdkHeader)
+	if sender.tokenSource != nil {
+		token, err := sender.tokenSource()
+		if err != nil {
+			return nil, &tokenError{err}
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if sender.signer != nil {
+		if err := sender.signer.Sign(req, data); err != nil {
+			return nil, err
+		}
+	}
+	rsp, err := sender.client.Do(req)
 	if err != nil {
-		log.Printf("Failed to post analytics events.  %v\n", err)
-		return
+		sender.endpoints.record(false, sender.clock.Now())
+		return nil, err
 	}
 	defer rsp.Body.Close()
 
-	if rsp.StatusCode != http.StatusOK {
-		log.Printf("Failure return for analytics post.  %d, %s\n", rsp.StatusCode, rsp.Status)
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		statusErr := &StatusError{StatusCode: rsp.StatusCode, Status: rsp.Status}
+		if rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(rsp.Header.Get("Retry-After"), sender.clock.Now())
+		}
+		// Only failures suggesting the endpoint is down count towards failing over
+		sender.endpoints.record(!retryable(statusErr), sender.clock.Now())
+		return nil, statusErr
+	}
+	sender.endpoints.record(true, sender.clock.Now())
+
+	rejected, err := sender.parser.Rejected(rsp)
+	if err != nil {
+		// The batch was accepted, but we can't tell whether every event was.  Assume they were
+		sender.errorHandler(fmt.Errorf("apinalytics: couldn't parse response. %v", err), nil)
 	}
+	return rejected, nil
 }
 
 func (sender *Sender) run() {
-	var event *AnalyticsEvent
+	sender.setFlushInterval(sender.flushInterval)
+	defer sender.setFlushInterval(0)
 
-	// Block for the first event, once we have one event we try to drain everthing left
-	for event = range sender.channel {
-		sender.add(event)
+	for {
+		// Without a flush interval tick stays nil and never fires, and we send whatever we have as soon as the channel
+		// is drained.  With a flush interval partial batches are held until the ticker fires.
+		var tick <-chan time.Time
+		if sender.ticker != nil {
+			tick = sender.ticker.C()
+		}
 
-		// Select with a default case is essentially a non-blocking read from the channel
-	Loop:
-		for {
-			select {
-			case event = <-sender.channel:
-				// Add the event to those we are batching
-				if !sender.add(event) {
-					break Loop
+		select {
+		case <-sender.quit:
+			// The sender has been closed.  Send anything left over and exit
+			sender.flush()
+			sender.closeTransport()
+			// Indicate that this thread is over
+			close(sender.done)
+			log.Printf("Analytics exited\n")
+			return
+
+		case event := <-sender.channel:
+			sender.add(event)
+			if tick == nil {
+				sender.drain()
+				// Send what we have batched
+				if !sender.isPaused() {
+					sender.send()
 				}
+			}
 
-			default:
-				// Nothing to batch at present.  Send our events if we have any, then go back to block until something
-				// shows up
-				break Loop
+		case <-tick:
+			// Send whatever is batched, even if the channel has been idle
+			if !sender.isPaused() {
+				sender.send()
 			}
+
+		case <-sender.resumed:
+			// Send everything buffered while we were paused
+			sender.send()
+
+		case fn := <-sender.reconfigure:
+			fn()
+
+		case reply := <-sender.flushes:
+			if sender.isPaused() {
+				sender.drain()
+				reply <- ErrPaused
+				continue
+			}
+			reply <- sender.flush()
 		}
-		// Send what we have batched
-		sender.send()
 	}
+}
+
+// Pull everything queued so far off the channel and send it, returning the first error encountered
+func (sender *Sender) flush() error {
+	for {
+		select {
+		case event := <-sender.channel:
+			sender.batch(event)
+
+		default:
+			return sender.send()
+		}
+	}
+}
 
-	// Indicate that this thread is over
-	sender.done <- true
-	log.Printf("Analytics exited\n")
+// Wait for d by the sender's clock, returning false early if the sender is abandoning sends
+func (sender *Sender) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return sender.ctx.Err() == nil
+	}
+	// Clocks only make tickers, but the first tick comes after d as a timer's would
+	ticker := sender.clock.NewTicker(d)
+	defer ticker.Stop()
+	select {
+	case <-ticker.C():
+		return true
+	case <-sender.ctx.Done():
+		return false
+	}
+}
+
+// Pull everything currently queued off the channel without blocking
+func (sender *Sender) drain() {
+	// Select with a default case is essentially a non-blocking read from the channel
+	for {
+		select {
+		case event := <-sender.channel:
+			// Add the event to those we are batching
+			sender.add(event)
+
+		default:
+			// Nothing to batch at present
+			return
+		}
+	}
 }
@@ -0,0 +1,68 @@
+package apinalytics_client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+Signer signs each POST to apinalytics.  Sign is called with the request, once its standard headers are set, and the
+exact bytes of its body.  It typically adds headers to the request.
+
+Implement Signer to support signing schemes other than HMACSigner.
+*/
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+/*
+WithSigner makes the sender sign each POST with signer.
+*/
+func WithSigner(signer Signer) Option {
+	return func(sender *Sender) {
+		sender.signer = signer
+	}
+}
+
+/*
+HMACSigner signs requests with HMAC-SHA256.
+
+The signature covers the request timestamp and body, so it can't be replayed later or applied to a different body.
+It is calculated over the decimal Unix timestamp, a newline, then the body, and sent as lower case hex.  The request
+carries
+
+	X-Auth-Timestamp: <unix seconds>
+	X-Auth-Signature: hmac-sha256=<hex signature>
+
+and the X-Auth-Key header is removed so the key itself never goes over the wire.
+*/
+type HMACSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+/*
+NewHMACSigner creates an HMACSigner that signs with key, usually your apinalytics write key.
+*/
+func NewHMACSigner(key []byte) *HMACSigner {
+	return &HMACSigner{key: key, now: time.Now}
+}
+
+// Sign adds a timestamp and HMAC-SHA256 signature to req.
+func (signer *HMACSigner) Sign(req *http.Request, body []byte) error {
+	timestamp := strconv.FormatInt(signer.now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+
+	req.Header.Del("X-Auth-Key")
+	req.Header.Set("X-Auth-Timestamp", timestamp)
+	req.Header.Set("X-Auth-Signature", "hmac-sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,178 @@
+package apinalytics_client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrSpoolFull is returned by FileSpool.Store when storing a batch would take the spool over its size limit.
+var ErrSpoolFull = errors.New("apinalytics: spool full")
+
+/*
+Spool persists batches of events that could not be sent because apinalytics was unreachable, so they can be sent
+once it is reachable again.  Add a spool to a Sender with WithSpool.
+
+The Sender calls Store when a batch fails with a network error or 5xx response, after any retries, and calls
+Replay after each batch it sends successfully.
+*/
+type Spool interface {
+	// Store saves a batch of events.  It returns an error if the batch could not be saved
+	Store(events []*AnalyticsEvent) error
+	// Replay calls send with each stored batch, oldest first, and removes the batches sent.  Replay stops at the
+	// first batch send fails on, keeping that batch and those after it for next time
+	Replay(send func(events []*AnalyticsEvent) error) error
+}
+
+/*
+WithSpool makes the sender store batches it can't send in spool, and replay them when apinalytics is reachable again.
+By default such batches are passed to the error handler and dropped.
+
+	spool, err := NewFileSpool("/var/spool/myapp/apinalytics", 64<<20)
+	if err != nil {
+		...
+	}
+	sender := NewSender(appId, key, url, WithSpool(spool))
+*/
+func WithSpool(spool Spool) Option {
+	return func(sender *Sender) {
+		sender.spool = spool
+	}
+}
+
+/*
+FileSpool is a Spool backed by an append-only file, holding one JSON encoded batch per line.  Create one with
+NewFileSpool.
+*/
+type FileSpool struct {
+	path     string
+	maxBytes int64
+	lock     sync.Mutex
+	size     int64 // Current size of the file
+}
+
+/*
+NewFileSpool creates a FileSpool that stores batches in the file at path, which is created if it does not exist.
+Batches left in the file by a previous process are replayed.  The file is not allowed to grow beyond maxBytes;
+batches that don't fit are rejected with ErrSpoolFull.
+*/
+func NewFileSpool(path string, maxBytes int64) (*FileSpool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &FileSpool{path: path, maxBytes: maxBytes, size: info.Size()}, nil
+}
+
+// Store appends a batch of events to the spool file.
+func (spool *FileSpool) Store(events []*AnalyticsEvent) error {
+	line, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	spool.lock.Lock()
+	defer spool.lock.Unlock()
+	if spool.size+int64(len(line)) > spool.maxBytes {
+		return ErrSpoolFull
+	}
+
+	f, err := os.OpenFile(spool.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	n, err := f.Write(line)
+	spool.size += int64(n)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Replay sends the batches in the spool file, oldest first, then rewrites the file with any that were not sent.
+func (spool *FileSpool) Replay(send func(events []*AnalyticsEvent) error) error {
+	spool.lock.Lock()
+	defer spool.lock.Unlock()
+	if spool.size == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(spool.path)
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+	var remaining bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if sendErr != nil {
+			// Already failed, keep everything else for later
+			remaining.Write(line)
+			remaining.WriteByte('\n')
+			continue
+		}
+		var events []*AnalyticsEvent
+		if err := json.Unmarshal(line, &events); err != nil {
+			// A corrupt line, perhaps from a crash mid-write.  It will never decode so drop it
+			continue
+		}
+		if sendErr = send(events); sendErr != nil {
+			remaining.Write(line)
+			remaining.WriteByte('\n')
+		}
+	}
+
+	// Replace the file with what's left.  Write to a temporary file first so a crash can't lose the spool
+	tmp := spool.path + ".tmp"
+	if err := os.WriteFile(tmp, remaining.Bytes(), 0600); err != nil {
+		return fmt.Errorf("apinalytics: couldn't rewrite spool. %v", err)
+	}
+	if err := os.Rename(tmp, spool.path); err != nil {
+		return fmt.Errorf("apinalytics: couldn't rewrite spool. %v", err)
+	}
+	spool.size = int64(remaining.Len())
+	return sendErr
+}
+
+// The error given for batches spooled without trying to send them, because older batches are spooled.  See
+// WithStrictOrdering
+var errSpoolBacklog = errors.New("apinalytics: waiting for spooled batches to be sent")
+
+// Send any batches spooled while apinalytics was unreachable.  Returns false if some are still spooled
+func (sender *Sender) replay() bool {
+	if sender.spool == nil {
+		return true
+	}
+	err := sender.spool.Replay(func(events []*AnalyticsEvent) error {
+		if !sender.breaker.allow(sender.clock.Now()) {
+			// Leave this batch and the rest spooled until the circuit closes
+			return ErrCircuitOpen
+		}
+		err := sender.deliver(events)
+		sender.breaker.record(err == nil || !retryable(err), sender.clock.Now())
+		if err != nil && !retryable(err) {
+			// apinalytics will never accept this batch, so there's no point keeping it
+			sender.fail(err, events)
+			return nil
+		}
+		return err
+	})
+	if err != nil && !retryable(err) {
+		// Something went wrong with the spool itself rather than with sending
+		sender.errorHandler(err, nil)
+	}
+	return err == nil
+}
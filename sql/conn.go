@@ -0,0 +1,193 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// A connection that reports its statements.  The optional interfaces are passed through to the driver's connection
+// where it implements them, otherwise we do what database/sql would have done without them
+type wrappedConn struct {
+	driver.Conn
+	tracker *tracker
+}
+
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedStmt{Stmt: stmt, query: query, tracker: c.tracker}, nil
+}
+
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedStmt{Stmt: stmt, query: query, tracker: c.tracker}, nil
+}
+
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	// As database/sql does, refuse options the driver can't honour rather than silently ignoring them
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := c.tracker.config.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.tracker.report("EXEC", query, start, err, resultData(result, err))
+	return result, err
+}
+
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := c.tracker.config.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		c.tracker.report("QUERY", query, start, err, nil)
+		return nil, err
+	}
+	return &wrappedRows{Rows: rows, query: query, start: start, tracker: c.tracker}, nil
+}
+
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// A prepared statement that reports each time it is run
+type wrappedStmt struct {
+	driver.Stmt
+	query   string
+	tracker *tracker
+}
+
+func (s *wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := s.tracker.config.Now()
+	result, err := s.Stmt.Exec(args)
+	s.tracker.report("EXEC", s.query, start, err, resultData(result, err))
+	return result, err
+}
+
+func (s *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+	start := s.tracker.config.Now()
+	result, err := execer.ExecContext(ctx, args)
+	s.tracker.report("EXEC", s.query, start, err, resultData(result, err))
+	return result, err
+}
+
+func (s *wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := s.tracker.config.Now()
+	rows, err := s.Stmt.Query(args)
+	if err != nil {
+		s.tracker.report("QUERY", s.query, start, err, nil)
+		return nil, err
+	}
+	return &wrappedRows{Rows: rows, query: s.query, start: start, tracker: s.tracker}, nil
+}
+
+func (s *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+	start := s.tracker.config.Now()
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		s.tracker.report("QUERY", s.query, start, err, nil)
+		return nil, err
+	}
+	return &wrappedRows{Rows: rows, query: s.query, start: start, tracker: s.tracker}, nil
+}
+
+func (s *wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// The Data for an exec
+func resultData(result driver.Result, err error) map[string]interface{} {
+	if err != nil || result == nil {
+		return nil
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil
+	}
+	return map[string]interface{}{"rows_affected": affected}
+}
+
+// Convert arguments for drivers that don't understand named values, as database/sql does
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.New("apinalytics: driver does not support the use of Named Parameters")
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,73 @@
+/*
+Package sql wraps database/sql drivers so that each query and exec is reported to Apinalytics, letting you correlate
+API latency with database latency in the same apinalytics project.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	sql.Register("postgres-apinalytics", Wrap(&pq.Driver{}, sender))
+	db, err := sql.Open("postgres-apinalytics", dsn)
+
+Events have Method "QUERY" or "EXEC", and Function set to the statement with literals replaced by ? and whitespace
+collapsed, so that the same statement with different arguments is grouped together.  StatusCode is 200 if the
+statement succeeded and 500 if not.  Execs report the rows affected in Data["rows_affected"], and queries report the
+number of rows read in Data["rows"] once the rows are closed.
+*/
+package sql
+
+import (
+	"database/sql/driver"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+Wrap returns a driver.Driver that reports the statements run through d to sender.  Register it with sql.Register.
+
+The driver takes options from the apinalytics_client package, e.g. WithRequestSampleRate, WithErrorsOnly, WithApdex
+or WithClock.  Statements have no HTTP request or path, so options that need one, such as WithRequestID,
+WithRequestCallback, WithExcludedPaths and WithRouteTags, have no effect.  ErrorMessage and ErrorType describe the
+error the statement failed with, if any.
+*/
+func Wrap(d driver.Driver, sender cli.Queuer, options ...cli.MiddlewareOption) driver.Driver {
+	t := &tracker{config: cli.MiddlewareConfig{Sender: sender}}
+	t.config.Apply(options...)
+	return &wrappedDriver{Driver: d, tracker: t}
+}
+
+// Builds and queues events
+type tracker struct {
+	config cli.MiddlewareConfig
+}
+
+// Report a statement that started at start.  Nothing is reported for driver.ErrSkip, as database/sql will try again
+// another way
+func (t *tracker) report(method, query string, start time.Time, err error, data map[string]interface{}) {
+	if err == driver.ErrSkip {
+		return
+	}
+	event := &cli.AnalyticsEvent{
+		Method:     method,
+		Function:   normalize(query),
+		StatusCode: 200,
+		Data:       data,
+	}
+	if err != nil {
+		event.StatusCode = 500
+		cli.SetError(event, err)
+	}
+	t.config.Finish(event, start)
+	t.config.Report(event)
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	tracker *tracker
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{Conn: conn, tracker: d.tracker}, nil
+}
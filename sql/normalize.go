@@ -0,0 +1,21 @@
+package sql
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Quoted strings, allowing for doubled quotes inside them
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// Numbers not part of an identifier or placeholder such as $1
+	numberLiteral = regexp.MustCompile(`([^\w$.])-?\d+(?:\.\d+)?`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// Reduce a statement to its shape, so the same statement run with different literals is reported the same way
+func normalize(query string) string {
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = numberLiteral.ReplaceAllString(" "+query, "${1}?")
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
@@ -0,0 +1,102 @@
+package apinalytics_client
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Stats is a snapshot of what a Sender has been doing, as returned by Sender.Stats.
+*/
+type Stats struct {
+	// Events waiting to be sent, either in the queue to the background goroutine or batched ready to send
+	Queued int
+	// Events successfully posted to apinalytics
+	EventsSent uint64
+	// Batches successfully posted to apinalytics
+	BatchesSent uint64
+	// Total time spent on the POSTs of the batches successfully sent
+	SendTime time.Duration
+	// Batches that could not be sent, after any retries
+	SendFailures uint64
+	// Events discarded because the queue was full
+	Dropped uint64
+	// The most recent send failure, or nil if there has been none
+	LastError error
+	// When LastError happened
+	LastErrorTime time.Time
+	// True while the sender is paused
+	Paused bool
+	// True while the circuit breaker is open and no attempt is being made to send
+	CircuitOpen bool
+	// True while the sender is holding off because apinalytics responded with Retry-After
+	Throttled bool
+	// When the sender will send again, if Throttled
+	ThrottledUntil time.Time
+}
+
+/*
+Stats returns a snapshot of the sender's statistics.  It is safe to call concurrently, e.g. from a health endpoint.
+*/
+func (sender *Sender) Stats() Stats {
+	stats := Stats{
+		Queued:       len(sender.channel) + int(atomic.LoadInt64(&sender.stats.batched)),
+		EventsSent:   atomic.LoadUint64(&sender.stats.eventsSent),
+		BatchesSent:  atomic.LoadUint64(&sender.stats.batchesSent),
+		SendTime:     time.Duration(atomic.LoadInt64(&sender.stats.sendTime)),
+		SendFailures: atomic.LoadUint64(&sender.stats.sendFailures),
+		Dropped:      atomic.LoadUint64(&sender.stats.dropped),
+		Paused:       sender.isPaused(),
+		CircuitOpen:  sender.breaker.isOpen(),
+	}
+	if until := atomic.LoadInt64(&sender.stats.throttledUntil); until > sender.clock.Now().UnixNano() {
+		stats.Throttled = true
+		stats.ThrottledUntil = time.Unix(0, until)
+	}
+	sender.stats.lock.Lock()
+	stats.LastError = sender.stats.lastError
+	stats.LastErrorTime = sender.stats.lastErrorTime
+	sender.stats.lock.Unlock()
+	return stats
+}
+
+// Counters behind Stats.  The 64-bit counters are updated atomically so must stay at the start of the struct
+type counters struct {
+	eventsSent     uint64
+	batchesSent    uint64
+	sendFailures   uint64
+	dropped        uint64
+	sendTime       int64      // Nanoseconds
+	throttledUntil int64      // Unix nanoseconds.  Don't send before this
+	batched        int64      // Events pulled off the channel and not yet sent
+	lock           sync.Mutex // Protects lastError and lastErrorTime
+	lastError      error
+	lastErrorTime  time.Time
+}
+
+// Record a batch of events sent successfully
+func (c *counters) sent(count int, took time.Duration) {
+	atomic.AddUint64(&c.eventsSent, uint64(count))
+	atomic.AddUint64(&c.batchesSent, 1)
+	atomic.AddInt64(&c.sendTime, int64(took))
+}
+
+// Record a batch that could not be sent at now
+func (c *counters) failed(err error, now time.Time) {
+	atomic.AddUint64(&c.sendFailures, 1)
+	c.lock.Lock()
+	c.lastError = err
+	c.lastErrorTime = now
+	c.lock.Unlock()
+}
+
+// Record that apinalytics has asked us not to send for a while
+func (c *counters) throttle(delay time.Duration, now time.Time) {
+	atomic.StoreInt64(&c.throttledUntil, now.Add(delay).UnixNano())
+}
+
+// How long after now until we may send again
+func (c *counters) throttleDelay(now time.Time) time.Duration {
+	return time.Unix(0, atomic.LoadInt64(&c.throttledUntil)).Sub(now)
+}
@@ -0,0 +1,53 @@
+package apinalytics_client
+
+/*
+StatusSampling decides which responses middleware reports according to their status codes, so that busy services can
+keep full visibility of failures without paying for every successful request.  The zero StatusSampling reports every
+response at the usual sample rate.
+*/
+type StatusSampling struct {
+	// Report only responses with status 400 and above
+	ErrorsOnly bool
+	// Sample rate for responses with status below 400.  Responses with status 400 and above are then always reported,
+	// whatever the sender's sample rate.  Zero to sample all responses at the usual rate
+	SuccessRate float64
+}
+
+/*
+Apply reports whether event should be reported, setting its SampleRate according to its StatusCode.
+*/
+func (s StatusSampling) Apply(event *AnalyticsEvent) bool {
+	if event.StatusCode >= 400 {
+		if s.SuccessRate > 0 {
+			// A rate of 1 overrides any rate set on the sender
+			event.SampleRate = 1
+		}
+		return true
+	}
+	if s.ErrorsOnly {
+		return false
+	}
+	if s.SuccessRate > 0 {
+		event.SampleRate = s.SuccessRate
+	}
+	return true
+}
+
+/*
+WithErrorsOnly makes the middleware report only responses with status 400 and above.
+*/
+func WithErrorsOnly() MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Status.ErrorsOnly = true
+	}
+}
+
+/*
+WithSuccessSampleRate makes the middleware report only a fraction rate of responses with status below 400, while
+always reporting those with status 400 and above.  See StatusSampling.
+*/
+func WithSuccessSampleRate(rate float64) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Status.SuccessRate = rate
+	}
+}
@@ -0,0 +1,63 @@
+package apinalytics_client
+
+import (
+	"sort"
+)
+
+/*
+LatencySummary summarizes the response times of the events in a batch for one Function, so apinalytics can draw
+dashboards without going through every event.
+*/
+type LatencySummary struct {
+	// Function the events are for
+	Function string `json:"function"`
+	// Number of events in the batch for Function
+	Count int `json:"count"`
+	// Percentiles of ResponseUS, in microseconds
+	P50 int `json:"p50_us"`
+	P95 int `json:"p95_us"`
+	P99 int `json:"p99_us"`
+}
+
+/*
+WithBatchSummaries adds a LatencySummary for each Function to every batch envelope.  It only has an effect along
+with WithEnvelope, so servers that expect a bare list of events still get one.
+*/
+func WithBatchSummaries() Option {
+	return func(sender *Sender) {
+		sender.summaries = true
+	}
+}
+
+// Summarize the response times of events by Function.  Summaries are in order of Function
+func summarize(events []*AnalyticsEvent) []LatencySummary {
+	times := make(map[string][]int)
+	for _, event := range events {
+		times[event.Function] = append(times[event.Function], event.ResponseUS)
+	}
+
+	summaries := make([]LatencySummary, 0, len(times))
+	for function, responseUS := range times {
+		sort.Ints(responseUS)
+		summaries = append(summaries, LatencySummary{
+			Function: function,
+			Count:    len(responseUS),
+			P50:      percentile(responseUS, 50),
+			P95:      percentile(responseUS, 95),
+			P99:      percentile(responseUS, 99),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Function < summaries[j].Function
+	})
+	return summaries
+}
+
+// The nearest rank percentile p of sorted, which must not be empty
+func percentile(sorted []int, p int) int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
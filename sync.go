@@ -0,0 +1,30 @@
+package apinalytics_client
+
+import (
+	"context"
+	"time"
+)
+
+/*
+WithSynchronousMode makes Queue and QueueContext send each event before returning, waiting at most timeout, for
+environments such as serverless functions and short-lived command line tools where the background goroutine may be
+frozen or killed before it gets round to sending.
+
+Events are still sent by the background goroutine, so batching, retries and error handling work as usual, but each
+call to Queue waits for everything queued so far to be posted.  If the timeout passes first the event stays queued and
+will be sent later if the process lives long enough.  QueueContext returns any error sending the event.
+*/
+func WithSynchronousMode(timeout time.Duration) Option {
+	return func(sender *Sender) {
+		if timeout > 0 {
+			sender.syncTimeout = timeout
+		}
+	}
+}
+
+// Wait for the background goroutine to send everything queued, for synchronous mode
+func (sender *Sender) sendNow(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, sender.syncTimeout)
+	defer cancel()
+	return sender.Flush(ctx)
+}
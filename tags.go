@@ -0,0 +1,43 @@
+package apinalytics_client
+
+/*
+RouteTags are static labels, e.g. "team": "payments" or "tier": "public", added to the Data of every event for
+requests whose path matches Path, so you can slice latency and error rates by ownership.  Path is either an exact
+path or a prefix ending in *, as in PathRules.
+*/
+type RouteTags struct {
+	Path string
+	Tags map[string]string
+}
+
+/*
+WithRouteTags makes the middleware add tags to the Data of events for requests whose path matches path, e.g.
+
+	apinalytics_client.WithRouteTags("/payments/*", map[string]string{"team": "payments", "tier": "public"})
+
+If several routes match a request their tags are all added, those added later winning where they share keys.  Tags
+are added before the request callback runs, so it can override them.
+*/
+func WithRouteTags(path string, tags map[string]string) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.Tags = append(c.Tags, RouteTags{Path: path, Tags: tags})
+	}
+}
+
+/*
+AddRouteTags adds to event's Data the tags of each of routes that matches path, as described under WithRouteTags.  It
+is for middleware outside this package.
+*/
+func AddRouteTags(event *AnalyticsEvent, path string, routes []RouteTags) {
+	for _, route := range routes {
+		if !matchPath([]string{route.Path}, path) {
+			continue
+		}
+		for key, value := range route.Tags {
+			if event.Data == nil {
+				event.Data = make(map[string]interface{}, len(route.Tags))
+			}
+			event.Data[key] = value
+		}
+	}
+}
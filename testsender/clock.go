@@ -0,0 +1,97 @@
+package testsender
+
+import (
+	"sync"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+FakeClock implements apinalytics_client.Clock with time that only moves when you call Advance, so tests can check
+timestamps and fire a Sender's flush interval without sleeping.  It is safe for concurrent use.
+*/
+type FakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+/*
+NewFakeClock creates a FakeClock set to now.
+*/
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+/*
+Now returns the clock's current time.
+*/
+func (clock *FakeClock) Now() time.Time {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	return clock.now
+}
+
+/*
+NewTicker returns a Ticker that ticks each time Advance moves the clock past a multiple of d.  Like a *time.Ticker it
+drops ticks if the receiver falls behind.
+*/
+func (clock *FakeClock) NewTicker(d time.Duration) cli.Ticker {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	ticker := &fakeTicker{
+		clock:  clock,
+		period: d,
+		next:   clock.now.Add(d),
+		c:      make(chan time.Time, 1),
+	}
+	clock.tickers = append(clock.tickers, ticker)
+	return ticker
+}
+
+/*
+Advance moves the clock forward by d, firing any tickers that fall due.
+*/
+func (clock *FakeClock) Advance(d time.Duration) {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	clock.now = clock.now.Add(d)
+	for _, ticker := range clock.tickers {
+		if clock.now.Before(ticker.next) {
+			continue
+		}
+		select {
+		case ticker.c <- clock.now:
+		default:
+		}
+		for !clock.now.Before(ticker.next) {
+			ticker.next = ticker.next.Add(ticker.period)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	period time.Duration
+	next   time.Time // When the ticker next fires
+	c      chan time.Time
+}
+
+func (ticker *fakeTicker) C() <-chan time.Time {
+	return ticker.c
+}
+
+func (ticker *fakeTicker) Stop() {
+	clock := ticker.clock
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	for i, t := range clock.tickers {
+		if t == ticker {
+			clock.tickers = append(clock.tickers[:i], clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
+
+var _ cli.Clock = (*FakeClock)(nil)
@@ -0,0 +1,253 @@
+package testsender
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+FakeCollector is an HTTP server standing in for the apinalytics service, for testing a Sender end to end.  It checks
+the X-Auth headers on each POST, decodes the batch (gzipped or not, with or without an envelope) and records it.  It
+can be told to fail POSTs with a given status so you can test how your Sender retries and handles errors.
+
+	collector := testsender.NewFakeCollector("myapp", "mykey")
+	defer collector.Close()
+	collector.FailNext(2, http.StatusServiceUnavailable)
+
+	sender := apinalytics_client.NewSender("myapp", "mykey", collector.URL)
+	:
+
+Only JSON batches are understood.  Create one with NewFakeCollector.
+*/
+type FakeCollector struct {
+	// URL to pass to NewSender
+	URL string
+
+	server        *httptest.Server
+	applicationId string
+	writeKey      string
+
+	lock       sync.Mutex
+	batches    []RecordedBatch
+	requests   int
+	failures   []int         // Statuses to return for the next POSTs
+	failStatus int           // Status to return for every POST once failures is used up.  Zero to accept
+	retryAfter string        // Retry-After header sent with failures
+	changed    chan struct{} // Closed and replaced each time a batch is recorded
+}
+
+/*
+RecordedBatch is a batch received by a FakeCollector.
+*/
+type RecordedBatch struct {
+	// The batch ID from the X-Batch-Id header
+	ID string
+	// Headers the batch was posted with
+	Header http.Header
+	// The events in the batch
+	Events []*cli.AnalyticsEvent
+}
+
+/*
+NewFakeCollector starts a FakeCollector that accepts POSTs authenticated with applicationId and writeKey.  If
+writeKey is empty only the application ID is checked, e.g. for senders that sign requests rather than sending the key.
+Close it when you're done.
+*/
+func NewFakeCollector(applicationId, writeKey string) *FakeCollector {
+	collector := &FakeCollector{
+		applicationId: applicationId,
+		writeKey:      writeKey,
+		changed:       make(chan struct{}),
+	}
+	collector.server = httptest.NewServer(http.HandlerFunc(collector.serveHTTP))
+	collector.URL = collector.server.URL
+	return collector
+}
+
+/*
+Close shuts the collector down.
+*/
+func (collector *FakeCollector) Close() {
+	collector.server.Close()
+}
+
+/*
+FailNext makes the collector reject the next n POSTs with status, e.g. 500, 429 or 413, before going back to its
+usual behaviour.  Failures requested by successive calls are returned in order.
+*/
+func (collector *FakeCollector) FailNext(n int, status int) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	for i := 0; i < n; i++ {
+		collector.failures = append(collector.failures, status)
+	}
+}
+
+/*
+FailAll makes the collector reject every POST with status until FailAll(0) is called.
+*/
+func (collector *FakeCollector) FailAll(status int) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	collector.failStatus = status
+}
+
+/*
+SetRetryAfter sets the Retry-After header sent with failed responses, e.g. "1" with a 429.  An empty value sends none.
+*/
+func (collector *FakeCollector) SetRetryAfter(value string) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	collector.retryAfter = value
+}
+
+/*
+Requests returns the number of POSTs received, including those that failed.
+*/
+func (collector *FakeCollector) Requests() int {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	return collector.requests
+}
+
+/*
+Batches returns the batches accepted so far, in the order they arrived.
+*/
+func (collector *FakeCollector) Batches() []RecordedBatch {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	batches := make([]RecordedBatch, len(collector.batches))
+	copy(batches, collector.batches)
+	return batches
+}
+
+/*
+Events returns the events from every batch accepted so far.
+*/
+func (collector *FakeCollector) Events() []*cli.AnalyticsEvent {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	var events []*cli.AnalyticsEvent
+	for _, batch := range collector.batches {
+		events = append(events, batch.Events...)
+	}
+	return events
+}
+
+/*
+EventCount returns the number of events accepted so far.
+*/
+func (collector *FakeCollector) EventCount() int {
+	return len(collector.Events())
+}
+
+/*
+WaitForEvents waits until at least n events have been accepted, or timeout has passed.  It returns true if n events
+were accepted in time.
+*/
+func (collector *FakeCollector) WaitForEvents(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		collector.lock.Lock()
+		changed := collector.changed
+		collector.lock.Unlock()
+		if collector.EventCount() >= n {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+func (collector *FakeCollector) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	collector.lock.Lock()
+	collector.requests++
+	collector.lock.Unlock()
+
+	if r.Method != "POST" {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("X-Auth-User") != collector.applicationId ||
+		(collector.writeKey != "" && r.Header.Get("X-Auth-Key") != collector.writeKey) {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+		return
+	}
+	if status, retryAfter := collector.failure(); status != 0 {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	events, err := decodeBatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collector.lock.Lock()
+	collector.batches = append(collector.batches, RecordedBatch{
+		ID:     r.Header.Get("X-Batch-Id"),
+		Header: r.Header.Clone(),
+		Events: events,
+	})
+	close(collector.changed)
+	collector.changed = make(chan struct{})
+	collector.lock.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Decide whether to fail this POST.  Returns the status to fail with, or zero, and any Retry-After header
+func (collector *FakeCollector) failure() (int, string) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	status := collector.failStatus
+	if len(collector.failures) > 0 {
+		status = collector.failures[0]
+		collector.failures = collector.failures[1:]
+	}
+	return status, collector.retryAfter
+}
+
+// Decode the events posted, whether a bare list or a Batch envelope
+func decodeBatch(r *http.Request) ([]*cli.AnalyticsEvent, error) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var batch cli.Batch
+		err := json.Unmarshal(data, &batch)
+		return batch.Events, err
+	}
+	var events []*cli.AnalyticsEvent
+	err = json.Unmarshal(data, &events)
+	return events, err
+}
@@ -0,0 +1,141 @@
+/*
+Package testsender contains a fake Sender for unit testing code instrumented with apinalytics, such as handlers
+wrapped in the middleware, without posting anything over HTTP.
+
+	sender := testsender.NewRecordingSender()
+	m.Use(goji.BuildMiddleWare("", "", "", nil, goji.WithSender(sender)))
+	:
+	if !sender.WaitForEvents(1, time.Second) {
+		t.Fatal("no event reported")
+	}
+*/
+package testsender
+
+import (
+	"sync"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+RecordingSender implements apinalytics_client.Queuer by storing the events queued to it in memory.  It is safe for
+concurrent use.  Create one with NewRecordingSender.
+*/
+type RecordingSender struct {
+	lock    sync.Mutex
+	events  []*cli.AnalyticsEvent
+	closed  bool
+	changed chan struct{} // Closed and replaced each time an event is recorded
+}
+
+/*
+NewRecordingSender creates an empty RecordingSender.
+*/
+func NewRecordingSender() *RecordingSender {
+	return &RecordingSender{changed: make(chan struct{})}
+}
+
+/*
+Queue records event.  Events queued after Close are discarded, as they would be by a real Sender.
+*/
+func (sender *RecordingSender) Queue(event *cli.AnalyticsEvent) {
+	if event == nil {
+		return
+	}
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	if sender.closed {
+		return
+	}
+	sender.events = append(sender.events, event)
+	close(sender.changed)
+	sender.changed = make(chan struct{})
+}
+
+/*
+Close stops the sender recording events.  Events already recorded are kept.
+*/
+func (sender *RecordingSender) Close() {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	sender.closed = true
+}
+
+/*
+Closed reports whether Close has been called.
+*/
+func (sender *RecordingSender) Closed() bool {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	return sender.closed
+}
+
+/*
+Events returns a copy of the list of events recorded so far, in the order they were queued.
+*/
+func (sender *RecordingSender) Events() []*cli.AnalyticsEvent {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	events := make([]*cli.AnalyticsEvent, len(sender.events))
+	copy(events, sender.events)
+	return events
+}
+
+/*
+EventCount returns the number of events recorded so far.
+*/
+func (sender *RecordingSender) EventCount() int {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	return len(sender.events)
+}
+
+/*
+EventsFor returns the events recorded so far whose Function is function.
+*/
+func (sender *RecordingSender) EventsFor(function string) []*cli.AnalyticsEvent {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	var events []*cli.AnalyticsEvent
+	for _, event := range sender.events {
+		if event.Function == function {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+/*
+WaitForEvents waits until at least n events have been recorded, or timeout has passed.  It returns true if n events
+were recorded in time.  Use it when events are queued from another goroutine, e.g. by a server under test.
+*/
+func (sender *RecordingSender) WaitForEvents(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		sender.lock.Lock()
+		count, changed := len(sender.events), sender.changed
+		sender.lock.Unlock()
+		if count >= n {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+/*
+Reset discards the events recorded so far.
+*/
+func (sender *RecordingSender) Reset() {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	sender.events = nil
+}
+
+var _ cli.Queuer = (*RecordingSender)(nil)
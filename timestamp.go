@@ -0,0 +1,68 @@
+package apinalytics_client
+
+import (
+	"time"
+)
+
+/*
+TimestampUnit is the precision of the Timestamps a Sender sends.  With the default, Seconds, events within the same
+second can't be told apart or ordered.
+*/
+type TimestampUnit int
+
+const (
+	// Whole seconds since 1 Jan 1970 UTC
+	Seconds TimestampUnit = iota
+	// Milliseconds since 1 Jan 1970 UTC
+	Milliseconds
+	// Microseconds since 1 Jan 1970 UTC
+	Microseconds
+)
+
+// String returns the abbreviation for unit sent in events' timestamp_unit field, e.g. "ms".
+func (unit TimestampUnit) String() string {
+	switch unit {
+	case Milliseconds:
+		return "ms"
+	case Microseconds:
+		return "us"
+	}
+	return "s"
+}
+
+/*
+WithTimestampUnit makes the sender send Timestamps in unit rather than seconds.  Each event's Timestamp is worked out
+from its Time, which the middleware sets, or if that's not set by scaling Timestamp, and its TimestampUnit is set to
+say which unit is used, e.g. "ms".  Only use this if your apinalytics server understands timestamp_unit.
+*/
+func WithTimestampUnit(unit TimestampUnit) Option {
+	return func(sender *Sender) {
+		sender.timeUnit = unit
+	}
+}
+
+/*
+SetTime sets the time of event to t, setting both Time and Timestamp.
+*/
+func (event *AnalyticsEvent) SetTime(t time.Time) {
+	event.Time = t
+	event.Timestamp = t.Unix()
+}
+
+// Set an event's Timestamp in unit.  Only called once per event, from the background goroutine
+func (unit TimestampUnit) stamp(event *AnalyticsEvent) {
+	if unit == Seconds {
+		return
+	}
+	t := event.Time
+	if t.IsZero() {
+		t = time.Unix(event.Timestamp, 0)
+	}
+	switch unit {
+	case Milliseconds:
+		event.Timestamp = t.UnixNano() / int64(time.Millisecond)
+	case Microseconds:
+		event.Timestamp = t.UnixNano() / int64(time.Microsecond)
+	}
+	event.TimestampUnit = unit.String()
+}
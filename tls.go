@@ -0,0 +1,40 @@
+package apinalytics_client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+/*
+WithTLSConfig makes the sender use config for HTTPS connections to apinalytics, e.g. to trust an internal CA, present
+a client certificate for mutual TLS or require a minimum TLS version.
+
+The config is applied to a copy of the sender's HTTP client and its transport, so neither http.DefaultClient nor a
+client given to WithHTTPClient is modified.  If the client given to WithHTTPClient has a Transport that isn't an
+*http.Transport the config can't be applied; this is reported to the error handler and the client is used as is.
+*/
+func WithTLSConfig(config *tls.Config) Option {
+	return func(sender *Sender) {
+		sender.tlsConfig = config
+	}
+}
+
+// Make a copy of the sender's client with a transport using the sender's TLS config
+func (sender *Sender) clientWithTLS() *http.Client {
+	var transport *http.Transport
+	switch t := sender.client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		sender.errorHandler(fmt.Errorf("apinalytics: can't apply TLS config to HTTP client transport of type %T", t), nil)
+		return sender.client
+	}
+	transport.TLSClientConfig = sender.tlsConfig
+
+	client := *sender.client
+	client.Transport = transport
+	return &client
+}
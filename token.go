@@ -0,0 +1,29 @@
+package apinalytics_client
+
+/*
+WithTokenSource calls source before every POST to apinalytics and sends the token it returns as an
+"Authorization: Bearer" header.  Use this when apinalytics sits behind a gateway requiring tokens that expire, so
+they can be refreshed without recreating the Sender.
+
+source is called from the sender's background goroutine, so should cache tokens rather than fetching a new one each
+time.  If source returns an error the POST is not attempted and the failure is treated like a network error, so it
+is retried according to the sender's RetryPolicy.
+*/
+func WithTokenSource(source func() (string, error)) Option {
+	return func(sender *Sender) {
+		sender.tokenSource = source
+	}
+}
+
+// A failure to get a token from the sender's token source
+type tokenError struct {
+	err error
+}
+
+func (err *tokenError) Error() string {
+	return "apinalytics: couldn't get token. " + err.err.Error()
+}
+
+func (err *tokenError) Unwrap() error {
+	return err.err
+}
@@ -0,0 +1,55 @@
+package apinalytics_client
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+ParseTraceparent parses a W3C Trace Context traceparent header (https://www.w3.org/TR/trace-context/), e.g.
+"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", returning the trace ID and the parent span ID.  ok is
+false if value isn't a valid traceparent.
+*/
+func ParseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Version 00 has exactly four fields.  Later versions may add more, and ff is forbidden
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// Check s is n lowercase hex digits, as the spec requires
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+SetTraceContext sets event's TraceId and SpanId from the traceparent header in header, if there is a valid one.  The
+middleware does this for every request.  It is for middleware outside this package.
+*/
+func SetTraceContext(event *AnalyticsEvent, header http.Header) {
+	if traceID, spanID, ok := ParseTraceparent(header.Get("Traceparent")); ok {
+		event.TraceId = traceID
+		event.SpanId = spanID
+	}
+}
@@ -0,0 +1,100 @@
+package apinalytics_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+)
+
+/*
+Transport delivers batches of events somewhere other than apinalytics' HTTP API, e.g. to a local agent or a message
+queue.  By default the Sender POSTs batches to the URL passed to NewSender; set a Transport WithTransport to replace
+that.
+
+Send makes a single attempt to deliver payload.  The Sender still batches, encodes, retries, spools and splits batches
+as usual, so Send should not retry itself.  Failures are only retried if they are wrapped with Temporary, and a batch
+that fails with ErrPayloadTooLarge is split in two and each half sent separately.  If some events in the batch couldn't
+be delivered Send can report them as rejected, as a ResponseParser does.
+
+If the Transport implements io.Closer it is closed when the Sender is, after the last batch is sent.
+*/
+type Transport interface {
+	Send(ctx context.Context, payload *Payload) ([]RejectedEvent, error)
+}
+
+/*
+Payload is a batch of events to be delivered by a Transport, both as events and as encoded by the Sender's Codec.
+Transports that encode events themselves can ignore Body.
+*/
+type Payload struct {
+	// The batch being sent
+	Batch *Batch
+	// The batch encoded by the Sender's Codec, and compressed if the Sender was created WithGzip
+	Body []byte
+	// Content-Type of Body, e.g. "application/json"
+	ContentType string
+	// "gzip" if Body is compressed, otherwise ""
+	ContentEncoding string
+}
+
+/*
+WithTransport makes the sender deliver batches with transport rather than POSTing them to apinalytics.  The URL passed
+to NewSender, and options that only affect POSTs such as WithHTTPClient, WithHeaders and WithFallbackURLs, are ignored.
+*/
+func WithTransport(transport Transport) Option {
+	return func(sender *Sender) {
+		sender.transport = transport
+	}
+}
+
+/*
+Temporary wraps an error returned by a Transport to say the failure is temporary, e.g. the far end is unreachable, so
+the batch should be retried or spooled.
+*/
+func Temporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &temporaryError{err}
+}
+
+type temporaryError struct {
+	err error
+}
+
+func (err *temporaryError) Error() string {
+	return err.err.Error()
+}
+
+func (err *temporaryError) Unwrap() error {
+	return err.err
+}
+
+// Check whether err was wrapped with Temporary
+func isTemporary(err error) bool {
+	var temporary *temporaryError
+	return errors.As(err, &temporary)
+}
+
+// Make a single attempt to deliver an encoded batch, with the sender's Transport if it has one, otherwise by POSTing
+// it to apinalytics
+func (sender *Sender) transmit(batch *Batch, data []byte, contentType string) ([]RejectedEvent, error) {
+	if sender.transport == nil {
+		return sender.postData(batch.ID, data, contentType)
+	}
+	payload := &Payload{Batch: batch, Body: data, ContentType: contentType}
+	if sender.gzip {
+		payload.ContentEncoding = "gzip"
+	}
+	return sender.transport.Send(sender.ctx, payload)
+}
+
+// Close the sender's Transport, if it needs closing.  Called once the last batch has been sent
+func (sender *Sender) closeTransport() {
+	if closer, ok := sender.transport.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close analytics transport.  %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Package twirp contains Twirp (https://github.com/twitchtv/twirp) server hooks for reporting RPCs to Apinalytics.
+
+To get started go to http://apinalytics.tanktop.tv/u to get an application Id and write key.
+*/
+package twirp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+	gotwirp "github.com/twitchtv/twirp"
+)
+
+/*
+ServerHooks returns Twirp server hooks that report each RPC to sender.
+
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "http://apinalytics.tanktop.tv/1/event/")
+	server := haberdasher.NewHaberdasherServer(impl, twirp.WithServerHooks(ServerHooks(sender, nil)))
+
+Use twirp.ChainHooks to combine them with your own hooks.  Events have Function set to the package, service and
+method, e.g. "twitch.twirp.example.Haberdasher/MakeHat", and ResponseUS set to the time between the request being
+received and the response being sent.  StatusCode is the HTTP status Twirp responded with, which for errors is the
+status Twirp maps the error code to.  The error code itself is reported in Data["twirp_code"].
+
+To add your own data to the events reported add a callback, e.g. to record the API consumer from a value your
+authentication middleware stored in the context.
+
+	callback := func(ctx context.Context, event *apinalytics_client.AnalyticsEvent) {
+		event.ConsumerId, _ = ctx.Value(userKey).(string)
+	}
+
+The hooks take options from the apinalytics_client package, e.g. WithRequestSampleRate, WithErrorsOnly or WithApdex.
+Twirp doesn't give hooks the HTTP request, so options that need it, such as WithRequestID and WithRequestCallback,
+have no effect.  Paths for WithExcludedPaths and WithRouteTags are "/" followed by Function.  ErrorMessage and
+ErrorType describe the error the call failed with, if any.
+*/
+func ServerHooks(sender cli.Queuer,
+	callback func(ctx context.Context, event *cli.AnalyticsEvent),
+	options ...cli.MiddlewareOption,
+) *gotwirp.ServerHooks {
+	config := cli.MiddlewareConfig{Sender: sender}
+	config.Apply(options...)
+
+	return &gotwirp.ServerHooks{
+		RequestReceived: func(ctx context.Context) (context.Context, error) {
+			return context.WithValue(ctx, callKey{}, &call{start: config.Now()}), nil
+		},
+		Error: func(ctx context.Context, err gotwirp.Error) context.Context {
+			if c, ok := ctx.Value(callKey{}).(*call); ok {
+				c.code = err.Code()
+				c.err = err
+			}
+			return ctx
+		},
+		ResponseSent: func(ctx context.Context) {
+			c, ok := ctx.Value(callKey{}).(*call)
+			if !ok {
+				return
+			}
+			event := &cli.AnalyticsEvent{
+				Method:     "POST",
+				Function:   function(ctx),
+				StatusCode: c.status(ctx),
+			}
+			event.Url = "/" + event.Function
+			event.Path = event.Url
+			if !config.Paths.Allow(event.Path) {
+				return
+			}
+			if c.code != gotwirp.NoError {
+				event.Data = map[string]interface{}{"twirp_code": string(c.code)}
+				cli.SetError(event, c.err)
+			}
+			config.Finish(event, c.start)
+			if callback != nil {
+				callback(ctx, event)
+			}
+			config.Report(event)
+		},
+	}
+}
+
+// The context key for a call
+type callKey struct{}
+
+// What we know about a call in progress
+type call struct {
+	start time.Time
+	code  gotwirp.ErrorCode // Set if the call failed
+	err   gotwirp.Error     // What the call failed with, if it did
+}
+
+// The HTTP status of the response to the call
+func (c *call) status(ctx context.Context) int {
+	if s, ok := gotwirp.StatusCode(ctx); ok {
+		if status, err := strconv.Atoi(s); err == nil {
+			return status
+		}
+	}
+	if c.code != gotwirp.NoError {
+		return gotwirp.ServerHTTPStatusFromErrorCode(c.code)
+	}
+	return http.StatusOK
+}
+
+// The full name of the method called, e.g. "twitch.twirp.example.Haberdasher/MakeHat"
+func function(ctx context.Context) string {
+	service, _ := gotwirp.ServiceName(ctx)
+	method, _ := gotwirp.MethodName(ctx)
+	if pkg, ok := gotwirp.PackageName(ctx); ok && pkg != "" {
+		service = pkg + "." + service
+	}
+	return service + "/" + method
+}
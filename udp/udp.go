@@ -0,0 +1,135 @@
+/*
+Package udp sends apinalytics events as UDP datagrams to a collector or agent on the local host, statsd style, for
+services where reporting must never hold anything up.
+
+	transport, err := udp.NewTransport("127.0.0.1:8126")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "", apinalytics_client.WithTransport(transport))
+
+Each event is encoded as a line of JSON, and as many lines as fit are packed into each datagram.  Delivery isn't
+acknowledged, so events may be lost, e.g. if the collector isn't running or is too busy to keep up, and failures are
+never retried.  Events too large to fit in a datagram on their own are rejected and passed to the Sender's error
+handler.
+*/
+package udp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+/*
+DefaultMaxDatagramSize is the default limit on the size of each datagram, small enough to avoid fragmentation on
+an Ethernet network.
+*/
+const DefaultMaxDatagramSize = 1432
+
+/*
+Transport is an apinalytics_client.Transport that sends events as UDP datagrams.  Create one with NewTransport.
+*/
+type Transport struct {
+	conn    net.Conn
+	maxSize int
+	dropped uint64 // Events lost because a datagram couldn't be written
+}
+
+// Option configures optional behaviour of a Transport.
+type Option func(*Transport)
+
+/*
+WithMaxDatagramSize limits each datagram to size bytes, e.g. larger for the loopback interface or smaller for
+networks with a small MTU.  The default is DefaultMaxDatagramSize.
+*/
+func WithMaxDatagramSize(size int) Option {
+	return func(transport *Transport) {
+		if size > 0 {
+			transport.maxSize = size
+		}
+	}
+}
+
+/*
+NewTransport creates a Transport sending to addr, e.g. "127.0.0.1:8126".
+*/
+func NewTransport(addr string, options ...Option) (*Transport, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("apinalytics: couldn't connect to %s. %v", addr, err)
+	}
+	transport := &Transport{conn: conn, maxSize: DefaultMaxDatagramSize}
+	for _, option := range options {
+		option(transport)
+	}
+	return transport, nil
+}
+
+/*
+Send sends the events in payload in as few datagrams as possible.  Events that are too large for a datagram are
+rejected.  Datagrams that can't be written are counted in Dropped rather than returned as errors.
+*/
+func (transport *Transport) Send(ctx context.Context, payload *cli.Payload) ([]cli.RejectedEvent, error) {
+	var rejected []cli.RejectedEvent
+	var datagram bytes.Buffer
+	count := 0 // Events in datagram
+	for i, event := range payload.Batch.Events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			rejected = append(rejected, cli.RejectedEvent{Index: i, Error: err.Error()})
+			continue
+		}
+		if len(line) > transport.maxSize {
+			rejected = append(rejected, cli.RejectedEvent{
+				Index: i,
+				Error: fmt.Sprintf("event is %d bytes, larger than the %d byte datagram limit", len(line), transport.maxSize),
+			})
+			continue
+		}
+		if datagram.Len() > 0 && datagram.Len()+1+len(line) > transport.maxSize {
+			transport.write(datagram.Bytes(), count)
+			datagram.Reset()
+			count = 0
+		}
+		if datagram.Len() > 0 {
+			datagram.WriteByte('\n')
+		}
+		datagram.Write(line)
+		count++
+	}
+	if datagram.Len() > 0 {
+		transport.write(datagram.Bytes(), count)
+	}
+	return rejected, nil
+}
+
+// Write a datagram holding count events.  Writing a datagram doesn't wait for it to be received, so errors are
+// rare, e.g. the collector wasn't listening for an earlier datagram.  We count them and move on
+func (transport *Transport) write(datagram []byte, count int) {
+	if _, err := transport.conn.Write(datagram); err != nil {
+		atomic.AddUint64(&transport.dropped, uint64(count))
+	}
+}
+
+/*
+Dropped returns the number of events lost because the datagrams holding them couldn't be sent.  Events lost on the way
+to the collector can't be counted.
+*/
+func (transport *Transport) Dropped() uint64 {
+	return atomic.LoadUint64(&transport.dropped)
+}
+
+/*
+Close closes the transport's socket.  The Sender closes its Transport when it is closed.
+*/
+func (transport *Transport) Close() error {
+	return transport.conn.Close()
+}
+
+var _ cli.Transport = (*Transport)(nil)
@@ -0,0 +1,144 @@
+/*
+Package unixsocket POSTs batches of apinalytics events over a Unix domain socket to a sidecar agent in the same pod or
+host, which then forwards them to apinalytics.  This saves each service a TCP connection and TLS handshake of its own,
+and lets the sidecar hold the credentials and take care of retries and spooling for all of them.
+
+	transport := unixsocket.NewTransport("/var/run/apinalytics/agent.sock")
+	sender := apinalytics_client.NewSender(myAppId, myWriteKey, "", apinalytics_client.WithTransport(transport))
+
+Batches are sent exactly as they would be to apinalytics, encoded by the Sender's Codec, but without the write key or
+any other credentials.  The sidecar should answer as apinalytics does: 2xx once it has taken responsibility for the
+batch, 207 Multi-Status for partial failures, 429 or 5xx if the Sender should retry.
+*/
+package unixsocket
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+// DefaultPath is the path batches are POSTed to.
+const DefaultPath = "/1/event/"
+
+// DefaultTimeout limits how long each POST to the sidecar may take.
+const DefaultTimeout = 5 * time.Second
+
+/*
+Transport is an apinalytics_client.Transport that POSTs batches over a Unix domain socket.  Create one with
+NewTransport.
+*/
+type Transport struct {
+	path   string
+	client *http.Client
+	parser cli.ResponseParser
+}
+
+// Option configures optional behaviour of a Transport.
+type Option func(*Transport)
+
+/*
+WithPath sets the path batches are POSTed to, if the sidecar doesn't use DefaultPath.
+*/
+func WithPath(path string) Option {
+	return func(transport *Transport) {
+		transport.path = path
+	}
+}
+
+/*
+WithTimeout limits how long each POST to the sidecar may take.  The default is DefaultTimeout.
+*/
+func WithTimeout(timeout time.Duration) Option {
+	return func(transport *Transport) {
+		transport.client.Timeout = timeout
+	}
+}
+
+/*
+WithResponseParser makes the transport use parser to find events the sidecar rejected from a batch.  The default is
+apinalytics_client.MultiStatusParser.
+*/
+func WithResponseParser(parser cli.ResponseParser) Option {
+	return func(transport *Transport) {
+		if parser != nil {
+			transport.parser = parser
+		}
+	}
+}
+
+/*
+NewTransport creates a Transport sending to the sidecar listening on socket, e.g. "/var/run/apinalytics/agent.sock".
+The socket isn't connected to until the first batch is sent.
+*/
+func NewTransport(socket string, options ...Option) *Transport {
+	dialer := &net.Dialer{}
+	transport := &Transport{
+		path:   DefaultPath,
+		parser: cli.MultiStatusParser{},
+		client: &http.Client{
+			Timeout: DefaultTimeout,
+			Transport: &http.Transport{
+				// Whatever address the request is for, connect to the socket
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+				MaxIdleConns:    4,
+				IdleConnTimeout: 90 * time.Second,
+			},
+		},
+	}
+	for _, option := range options {
+		option(transport)
+	}
+	return transport
+}
+
+/*
+Send POSTs the encoded batch in payload to the sidecar.  Responses other than 2xx are returned as
+apinalytics_client.StatusErrors, so the Sender retries them as it would a response from apinalytics.
+*/
+func (transport *Transport) Send(ctx context.Context, payload *cli.Payload) ([]cli.RejectedEvent, error) {
+	// The host is ignored, but must be there for the URL to be valid
+	req, err := http.NewRequestWithContext(ctx, "POST", "http://localhost"+transport.path, bytes.NewReader(payload.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", payload.ContentType)
+	if payload.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", payload.ContentEncoding)
+	}
+	req.Header.Set("X-Batch-Id", payload.Batch.ID)
+	req.Header.Set("X-Apinalytics-SDK", "go/"+cli.SDKVersion)
+
+	rsp, err := transport.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, &cli.StatusError{StatusCode: rsp.StatusCode, Status: rsp.Status}
+	}
+	rejected, err := transport.parser.Rejected(rsp)
+	if err != nil {
+		// The batch was accepted, so it mustn't be sent again.  Assume every event was
+		log.Printf("Couldn't parse response from apinalytics sidecar.  %v\n", err)
+	}
+	return rejected, nil
+}
+
+/*
+Close closes any idle connections to the sidecar.  The Sender closes its Transport when it is closed.
+*/
+func (transport *Transport) Close() error {
+	transport.client.CloseIdleConnections()
+	return nil
+}
+
+var _ cli.Transport = (*Transport)(nil)
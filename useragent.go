@@ -0,0 +1,115 @@
+package apinalytics_client
+
+import (
+	"strings"
+)
+
+/*
+UserAgentParser classifies a User-Agent header into the client's family (e.g. "curl" or "Chrome"), version and
+operating system.  It returns "" for anything it can't tell.
+*/
+type UserAgentParser func(userAgent string) (family, version, os string)
+
+// Browser products, most specific first as browsers mention the ones they're compatible with too
+var browsers = []struct{ token, name string }{
+	{"Edg", "Edge"},
+	{"OPR", "Opera"},
+	{"Firefox", "Firefox"},
+	{"Chrome", "Chrome"},
+	{"Safari", "Safari"},
+}
+
+// Operating systems, as they appear in User-Agent comments
+var operatingSystems = []struct{ token, name string }{
+	{"Windows", "Windows"},
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"Mac OS X", "macOS"},
+	{"Darwin", "macOS"},
+	{"Linux", "Linux"},
+}
+
+/*
+ParseUserAgent is a simple UserAgentParser that understands the product/version form used by HTTP client libraries
+and SDKs, e.g. "my-sdk/1.2.3 (linux; amd64)", and the common browsers.
+*/
+func ParseUserAgent(userAgent string) (family, version, os string) {
+	products := strings.Fields(userAgent)
+	if len(products) == 0 {
+		return "", "", ""
+	}
+	family, version = splitProduct(products[0])
+	if family == "Mozilla" {
+		// A browser.  Look for the real product
+		family, version = "", ""
+		for _, browser := range browsers {
+			for _, product := range products[1:] {
+				if name, v := splitProduct(product); name == browser.token {
+					family, version = browser.name, v
+					break
+				}
+			}
+			if family != "" {
+				break
+			}
+		}
+		if family == "Safari" {
+			// Safari's real version is in Version/x
+			for _, product := range products {
+				if name, v := splitProduct(product); name == "Version" {
+					version = v
+				}
+			}
+		}
+	}
+
+	lower := strings.ToLower(userAgent)
+	for _, candidate := range operatingSystems {
+		if strings.Contains(lower, strings.ToLower(candidate.token)) {
+			os = candidate.name
+			break
+		}
+	}
+	return family, version, os
+}
+
+// Split a product token such as "curl/7.68.0" into name and version
+func splitProduct(product string) (string, string) {
+	if i := strings.IndexByte(product, '/'); i >= 0 {
+		return product[:i], product[i+1:]
+	}
+	return product, ""
+}
+
+/*
+WithUserAgentParser makes the middleware classify each request's User-Agent with parser, reporting the client's
+family, version and operating system in Data["ua_family"], Data["ua_version"] and Data["ua_os"].  ParseUserAgent is a
+simple parser.
+*/
+func WithUserAgentParser(parser UserAgentParser) MiddlewareOption {
+	return func(c *MiddlewareConfig) {
+		c.UserAgent = parser
+	}
+}
+
+/*
+SetUserAgent sets event's UserAgent to userAgent and, if parser isn't nil, reports what it makes of it in Data, as
+described under WithUserAgentParser.  It is for middleware outside this package.
+*/
+func SetUserAgent(event *AnalyticsEvent, userAgent string, parser UserAgentParser) {
+	event.UserAgent = userAgent
+	if parser == nil || userAgent == "" {
+		return
+	}
+	family, version, os := parser(userAgent)
+	for key, value := range map[string]string{"ua_family": family, "ua_version": version, "ua_os": os} {
+		if value == "" {
+			continue
+		}
+		if event.Data == nil {
+			event.Data = make(map[string]interface{}, 3)
+		}
+		event.Data[key] = value
+	}
+}
@@ -1,6 +1,9 @@
 package apinalytics_client
 
 import (
+	"bufio"
+	"io"
+	"net"
 	"net/http"
 )
 
@@ -9,9 +12,80 @@ type StatusTrackingResponseWriter struct {
 	http.ResponseWriter
 	// http status code written
 	Status int
+	// Number of bytes written to the response body
+	Bytes int64
 }
 
 func (w *StatusTrackingResponseWriter) WriteHeader(status int) {
 	w.Status = status
 	w.ResponseWriter.WriteHeader(status)
 }
+
+func (w *StatusTrackingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.Bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *StatusTrackingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+/*
+TrackResponse wraps w to track the status and size of the response.  Pass the returned ResponseWriter to your handler
+and read the status and size from the returned StatusTrackingResponseWriter afterwards.
+
+Unlike a bare StatusTrackingResponseWriter, the returned ResponseWriter implements http.Flusher, http.Hijacker,
+http.Pusher and io.ReaderFrom when w does, as is usual for HTTP/1.x and HTTP/2 connections, so server-sent events,
+WebSocket upgrades and sendfile keep working in the handlers you instrument.
+*/
+func TrackResponse(w http.ResponseWriter) (http.ResponseWriter, *StatusTrackingResponseWriter) {
+	tracker := &StatusTrackingResponseWriter{ResponseWriter: w, Status: http.StatusOK}
+
+	_, flusher := w.(http.Flusher)
+	_, hijacker := w.(http.Hijacker)
+	_, pusher := w.(http.Pusher)
+	_, readerFrom := w.(io.ReaderFrom)
+	switch {
+	case flusher && hijacker && readerFrom:
+		// HTTP/1.x
+		return &http1Writer{flushWriter{tracker}}, tracker
+	case flusher && pusher:
+		// HTTP/2
+		return &http2Writer{flushWriter{tracker}}, tracker
+	case flusher:
+		return &flushWriter{tracker}, tracker
+	}
+	return tracker, tracker
+}
+
+type flushWriter struct {
+	*StatusTrackingResponseWriter
+}
+
+func (w *flushWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type http1Writer struct {
+	flushWriter
+}
+
+func (w *http1Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *http1Writer) ReadFrom(r io.Reader) (int64, error) {
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+	w.Bytes += n
+	return n, err
+}
+
+type http2Writer struct {
+	flushWriter
+}
+
+func (w *http2Writer) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
@@ -0,0 +1,124 @@
+package apinalytics_client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxFieldLength is the longest string Validate accepts in an event field or Data value.
+	MaxFieldLength = 4096
+	// MaxDataKeys is the most Data entries Validate accepts in an event.
+	MaxDataKeys = 64
+	// MaxDataKeyLength is the longest Data key Validate accepts.
+	MaxDataKeyLength = 128
+)
+
+/*
+ValidationError is returned by Validate for an event apinalytics would reject, listing everything wrong with it.
+*/
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "apinalytics: invalid event: " + strings.Join(err.Problems, "; ")
+}
+
+/*
+Validate checks event has everything apinalytics requires, returning a *ValidationError if it doesn't, with the
+problems in the same order each time.  It checks that
+
+  - Timestamp is set
+  - HTTP events have Method set and a valid HTTP StatusCode.  Other kinds need Function set, and StatusCode to be zero
+    or valid
+  - no string field or Data value is longer than MaxFieldLength
+  - there are no more than MaxDataKeys Data entries, their keys are no longer than MaxDataKeyLength and made up of
+    letters, digits, '.', '_' and '-', and their values are JSON serializable
+*/
+func (event *AnalyticsEvent) Validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if event.Timestamp <= 0 {
+		problem("no timestamp")
+	}
+	if event.IsHTTP() {
+		if event.Method == "" {
+			problem("no method")
+		}
+		if event.StatusCode < 100 || event.StatusCode > 599 {
+			problem("status %d out of range", event.StatusCode)
+		}
+	} else {
+		if event.Function == "" {
+			problem("no name for %s event", event.Kind)
+		}
+		if event.StatusCode != 0 && (event.StatusCode < 100 || event.StatusCode > 599) {
+			problem("status %d out of range", event.StatusCode)
+		}
+	}
+	// In a fixed order, so the problems are listed the same way each time
+	for _, field := range []struct{ name, value string }{
+		{"consumer_id", event.ConsumerId}, {"request_id", event.RequestId}, {"method", event.Method},
+		{"url", event.Url}, {"path", event.Path}, {"query", event.Query}, {"function", event.Function},
+		{"user_agent", event.UserAgent}, {"error_message", event.ErrorMessage},
+	} {
+		if len(field.value) > MaxFieldLength {
+			problem("%s longer than %d bytes", field.name, MaxFieldLength)
+		}
+	}
+
+	if len(event.Data) > MaxDataKeys {
+		problem("more than %d data entries", MaxDataKeys)
+	}
+	keys := make([]string, 0, len(event.Data))
+	for key := range event.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := event.Data[key]
+		if !validDataKey(key) {
+			problem("invalid data key %q", key)
+		}
+		if s, ok := value.(string); ok && len(s) > MaxFieldLength {
+			problem("data %q longer than %d bytes", key, MaxFieldLength)
+		} else if err := checkValue(value); err != nil {
+			problem("data %q not serializable. %v", key, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Check a Data key is acceptable
+func validDataKey(key string) bool {
+	if key == "" || len(key) > MaxDataKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+WithStrictMode makes the sender Validate every event before batching it.  Invalid events are discarded, counted in
+Stats().Dropped, and passed to the error handler with the *ValidationError, so one bad event can't get a whole batch
+rejected.  Validation happens in the background goroutine after enrichers, scrubbing and so on.
+*/
+func WithStrictMode() Option {
+	return func(sender *Sender) {
+		sender.strict = true
+	}
+}
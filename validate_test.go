@@ -0,0 +1,27 @@
+package apinalytics_client_test
+
+import (
+	"strings"
+	"testing"
+
+	cli "github.com/apinalytics/apinalytics_client"
+)
+
+func TestValidateProblemOrderIsStable(t *testing.T) {
+	long := strings.Repeat("x", cli.MaxFieldLength+1)
+	event := &cli.AnalyticsEvent{
+		Timestamp:  1000,
+		Method:     "GET",
+		StatusCode: 200,
+		Url:        long,
+		ConsumerId: long,
+		Data:       map[string]interface{}{"c d": 1, "a b": 1, "b c": 1},
+	}
+	want := `apinalytics: invalid event: consumer_id longer than 4096 bytes; url longer than 4096 bytes; ` +
+		`invalid data key "a b"; invalid data key "b c"; invalid data key "c d"`
+	for i := 0; i < 10; i++ {
+		if err := event.Validate(); err == nil || err.Error() != want {
+			t.Fatalf("Expected %s, got %v", want, err)
+		}
+	}
+}